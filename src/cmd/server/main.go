@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -26,6 +27,12 @@ import (
 func main() {
 	port := getenv("PORT", "8080")
 	dsn := getenv("DATABASE_URL", "postgres://pguser:pgpass@db:5432/prdb?sslmode=disable")
+	assignMode := getenv("ASSIGN_MODE", service.StrategyRandom)
+	idempotencyTTLRaw := getenv("IDEMPOTENCY_TTL", "24h")
+	requiredApprovalsRaw := getenv("REQUIRED_APPROVALS", "0")
+	assignSeedRaw := getenv("ASSIGN_SEED", strconv.FormatInt(time.Now().UnixNano(), 10))
+	capacityOverflow := getenv("CAPACITY_OVERFLOW", service.CapacityOverflowFallback)
+	adminToken := os.Getenv("ADMIN_TOKEN")
 
 	migDir := flag.String("migrations", "./migrations", "migrations directory")
 	flag.Parse()
@@ -39,6 +46,22 @@ func main() {
 	}(logger)
 	sugar := logger.Sugar()
 
+	idempotencyTTL, err := time.ParseDuration(idempotencyTTLRaw)
+	if err != nil || idempotencyTTL <= 0 {
+		sugar.Fatalf("invalid IDEMPOTENCY_TTL %q", idempotencyTTLRaw)
+	}
+	requiredApprovals, err := strconv.Atoi(requiredApprovalsRaw)
+	if err != nil || requiredApprovals < 0 {
+		sugar.Fatalf("invalid REQUIRED_APPROVALS %q", requiredApprovalsRaw)
+	}
+	assignSeed, err := strconv.ParseInt(assignSeedRaw, 10, 64)
+	if err != nil {
+		sugar.Fatalf("invalid ASSIGN_SEED %q", assignSeedRaw)
+	}
+	if !service.ValidCapacityOverflow(capacityOverflow) {
+		sugar.Fatalf("invalid CAPACITY_OVERFLOW %q", capacityOverflow)
+	}
+
 	db, err := connectDBWithRetry(dsn, 15, 2*time.Second, sugar)
 	if err != nil {
 		sugar.Fatalf("failed to connect to db: %v", err)
@@ -56,13 +79,29 @@ func main() {
 	sugar.Info("migrations applied")
 
 	repos := store.NewRepositories(db, sugar.Desugar())
-	svc := service.NewService(repos, sugar.Desugar())
-	h := api2.NewHandler(svc, sugar.Desugar())
+	strategy, err := service.NewStrategy(assignMode, repos)
+	if err != nil {
+		sugar.Fatalf("invalid ASSIGN_MODE: %v", err)
+	}
+	sugar.Infof("reviewer assignment strategy: %s, seed: %d", strategy.Name(), assignSeed)
+	svc := service.NewService(repos, sugar.Desugar(),
+		service.WithStrategies(service.BuiltinStrategies(repos)...),
+		service.WithStrategy(strategy),
+		service.WithSeed(assignSeed),
+		service.WithRequiredApprovals(requiredApprovals),
+		service.WithCapacityOverflow(capacityOverflow),
+	)
+	h := api2.NewHandler(svc, sugar.Desugar(), api2.WithAdminToken(adminToken))
 
 	r := chi.NewRouter()
-	r.Use(api2.RequestIDMiddleware, api2.LoggerMiddleware(logger), api2.Recoverer)
+	r.Use(api2.RequestIDMiddleware, api2.LoggerMiddleware(logger), api2.Recoverer,
+		api2.IdempotencyMiddleware(repos, idempotencyTTL, logger))
 	api2.RegisterRoutes(r, h)
 
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go purgeIdempotencyKeys(purgeCtx, repos, time.Hour, sugar)
+
 	srv := &http.Server{
 		Addr:         ":" + port,
 		Handler:      r,
@@ -98,6 +137,23 @@ func getenv(key, def string) string {
 	return def
 }
 
+func purgeIdempotencyKeys(ctx context.Context, repos *store.Repositories, every time.Duration, sugar *zap.SugaredLogger) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if n, err := repos.DeleteExpiredIdempotencyKeys(ctx, now); err != nil {
+				sugar.Warnf("purge idempotency keys: %v", err)
+			} else if n > 0 {
+				sugar.Infof("purged %d expired idempotency keys", n)
+			}
+		}
+	}
+}
+
 func connectDBWithRetry(dsn string, attempts int, delay time.Duration, sugar *zap.SugaredLogger) (*sql.DB, error) {
 	var db *sql.DB
 	var err error
@@ -5,18 +5,38 @@ import "fmt"
 type ErrorCode string
 
 const (
-	TeamExists      ErrorCode = "TEAM_EXISTS"
-	PRExists        ErrorCode = "PR_EXISTS"
-	PRAlreadyMerged ErrorCode = "PR_MERGED"
-	NotAssigned     ErrorCode = "NOT_ASSIGNED"
-	NoCandidate     ErrorCode = "NO_CANDIDATE"
-	NotFound        ErrorCode = "NOT_FOUND"
-	InternalError   ErrorCode = "INTERNAL_ERROR"
+	TeamExists           ErrorCode = "TEAM_EXISTS"
+	PRExists             ErrorCode = "PR_EXISTS"
+	PRAlreadyMerged      ErrorCode = "PR_MERGED"
+	PRClosed             ErrorCode = "PR_CLOSED"
+	PRDraft              ErrorCode = "PR_DRAFT"
+	PRNotDraft           ErrorCode = "PR_NOT_DRAFT"
+	PRNotClosed          ErrorCode = "PR_NOT_CLOSED"
+	NotAssigned          ErrorCode = "NOT_ASSIGNED"
+	AlreadyAssigned      ErrorCode = "ALREADY_ASSIGNED"
+	ReviewerIsAuthor     ErrorCode = "REVIEWER_IS_AUTHOR"
+	ReviewerNotInTeam    ErrorCode = "REVIEWER_NOT_IN_TEAM"
+	ReviewerInactive     ErrorCode = "REVIEWER_INACTIVE"
+	ReviewerAbsent       ErrorCode = "REVIEWER_ABSENT"
+	NoCandidate          ErrorCode = "NO_CANDIDATE"
+	NotFound             ErrorCode = "NOT_FOUND"
+	InvalidArgument      ErrorCode = "INVALID_ARGUMENT"
+	NotEnoughReviewers   ErrorCode = "NOT_ENOUGH_REVIEWERS"
+	NotEnoughSeniors     ErrorCode = "NOT_ENOUGH_SENIORS"
+	Conflict             ErrorCode = "CONFLICT"
+	PreconditionFailed   ErrorCode = "PRECONDITION_FAILED"
+	IdempotencyKeyReused ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	MergeBlocked         ErrorCode = "MERGE_BLOCKED"
+	CapacityExceeded     ErrorCode = "CAPACITY_EXCEEDED"
+	Forbidden            ErrorCode = "FORBIDDEN"
+	InternalError        ErrorCode = "INTERNAL_ERROR"
 )
 
+// APIError is a domain error returned to clients. Details, if set, is rendered next to code and message.
 type APIError struct {
 	Code    ErrorCode
 	Message string
+	Details any
 }
 
 func (e APIError) Error() string {
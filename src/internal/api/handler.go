@@ -2,12 +2,16 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
 	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
 	"github.com/ce-fello/pr-reviewer-service/src/internal/service"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -16,21 +20,57 @@ import (
 )
 
 type Handler struct {
-	svc *service.Service
-	log *zap.Logger
+	svc        *service.Service
+	log        *zap.Logger
+	adminToken string
 }
 
-func NewHandler(svc *service.Service, logger *zap.Logger) *Handler {
-	return &Handler{svc: svc, log: logger}
+type HandlerOption func(*Handler)
+
+// WithAdminToken enables admin-only actions for requests carrying the token in X-Admin-Token.
+func WithAdminToken(token string) HandlerOption {
+	return func(h *Handler) {
+		h.adminToken = token
+	}
+}
+
+func NewHandler(svc *service.Service, logger *zap.Logger, opts ...HandlerOption) *Handler {
+	h := &Handler{svc: svc, log: logger}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 func RegisterRoutes(r *chi.Mux, h *Handler) {
 	r.Post("/team/add", withTimeout(h.createTeam))
 	r.Get("/team/get", withTimeout(h.getTeam))
+	r.Get("/team/settings", withTimeout(h.getTeamSettings))
+	r.Post("/team/settings", withTimeout(h.updateTeamSettings))
+	r.Post("/team/deactivateUsers", withTimeout(h.deactivateTeamUsers))
+	r.Post("/team/setMemberRole", withTimeout(h.setMemberRole))
+	r.Get("/team/fairness", withTimeout(h.getFairness))
+	r.Get("/codeowners/get", withTimeout(h.getCodeOwners))
+	r.Post("/codeowners/set", withTimeout(h.setCodeOwners))
 	r.Post("/users/setIsActive", withTimeout(h.setIsActive))
+	r.Post("/users/setSkills", withTimeout(h.setSkills))
+	r.Post("/users/setMaxOpenReviews", withTimeout(h.setMaxOpenReviews))
+	r.Get("/users/getSkills", withTimeout(h.getSkills))
+	r.Post("/users/addAbsence", withTimeout(h.addAbsence))
+	r.Get("/users/getAbsences", withTimeout(h.getAbsences))
+	r.Post("/users/updateAbsence", withTimeout(h.updateAbsence))
+	r.Post("/users/deleteAbsence", withTimeout(h.deleteAbsence))
 	r.Post("/pullRequest/create", withTimeout(h.createPR))
+	r.Post("/pullRequest/preview", withTimeout(h.previewPR))
 	r.Post("/pullRequest/merge", withTimeout(h.mergePR))
 	r.Post("/pullRequest/reassign", withTimeout(h.reassign))
+	r.Post("/pullRequest/addReviewer", withTimeout(h.addReviewer))
+	r.Post("/pullRequest/removeReviewer", withTimeout(h.removeReviewer))
+	r.Post("/pullRequest/review", withTimeout(h.review))
+	r.Post("/pullRequest/ready", withTimeout(h.markReady))
+	r.Post("/pullRequest/close", withTimeout(h.closePR))
+	r.Post("/pullRequest/reopen", withTimeout(h.reopenPR))
+	r.Get("/pullRequest/history", withTimeout(h.getPRHistory))
 	r.Get("/users/getReview", withTimeout(h.getUserPRs))
 	r.Get("/stats", withTimeout(h.getStats))
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -84,10 +124,116 @@ func (h *Handler) getTeam(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, team)
 }
 
-func (h *Handler) setIsActive(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) getTeamSettings(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "team_name required")
+		return
+	}
+	settings, err := h.svc.GetTeamSettings(r.Context(), teamName)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) updateTeamSettings(w http.ResponseWriter, r *http.Request) {
 	var req struct {
+		TeamName string `json:"team_name"`
+		service.TeamSettingsUpdate
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TeamName == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "team_name required")
+		return
+	}
+	settings, err := h.svc.UpdateTeamSettings(r.Context(), req.TeamName, req.TeamSettingsUpdate)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"settings": settings})
+}
+
+func (h *Handler) setMemberRole(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string `json:"team_name"`
 		UserID   string `json:"user_id"`
-		IsActive bool   `json:"is_active"`
+		Role     string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TeamName == "" || req.UserID == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "team_name and user_id required")
+		return
+	}
+	user, err := h.svc.SetMemberRole(r.Context(), req.TeamName, req.UserID, req.Role)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"user": user})
+}
+
+func (h *Handler) getFairness(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "team_name required")
+		return
+	}
+	report, err := h.svc.GetFairnessReport(r.Context(), teamName)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *Handler) deactivateTeamUsers(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string   `json:"team_name"`
+		UserIDs  []string `json:"user_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TeamName == "" || len(req.UserIDs) == 0 {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "team_name and user_ids required")
+		return
+	}
+	result, err := h.svc.DeactivateTeamUsers(r.Context(), req.TeamName, req.UserIDs)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) getCodeOwners(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.svc.GetCodeOwners(r.Context())
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"rules": rules})
+}
+
+func (h *Handler) setCodeOwners(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Rules []model.CodeOwnerRule `json:"rules"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "rules required")
+		return
+	}
+	rules, err := h.svc.SetCodeOwners(r.Context(), req.Rules)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"rules": rules})
+}
+
+func (h *Handler) setIsActive(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID       string `json:"user_id"`
+		IsActive     bool   `json:"is_active"`
+		ReassignOpen bool   `json:"reassign_open"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
 		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "user_id required")
@@ -98,40 +244,210 @@ func (h *Handler) setIsActive(w http.ResponseWriter, r *http.Request) {
 		handleSvcError(w, err)
 		return
 	}
+	if req.IsActive || !req.ReassignOpen {
+		writeJSON(w, http.StatusOK, map[string]any{"user": user})
+		return
+	}
+	report, err := h.svc.ReassignOpenReviews(r.Context(), req.UserID)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"user": user, "reassignment": report})
+}
+
+func (h *Handler) setMaxOpenReviews(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID         string `json:"user_id"`
+		MaxOpenReviews *int   `json:"max_open_reviews"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "user_id required")
+		return
+	}
+	user, err := h.svc.SetUserMaxOpenReviews(r.Context(), req.UserID, req.MaxOpenReviews)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
 	writeJSON(w, http.StatusOK, map[string]any{"user": user})
 }
 
+func (h *Handler) setSkills(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string   `json:"user_id"`
+		Skills []string `json:"skills"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "user_id required")
+		return
+	}
+	skills, err := h.svc.SetUserSkills(r.Context(), req.UserID, req.Skills)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, skills)
+}
+
+func (h *Handler) getSkills(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "user_id required")
+		return
+	}
+	skills, err := h.svc.GetUserSkills(r.Context(), userID)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, skills)
+}
+
+func (h *Handler) addAbsence(w http.ResponseWriter, r *http.Request) {
+	var req model.Absence
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" || req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "user_id, starts_at and ends_at required")
+		return
+	}
+	absence, err := h.svc.AddAbsence(r.Context(), req)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"absence": absence})
+}
+
+func (h *Handler) getAbsences(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "user_id required")
+		return
+	}
+	absences, err := h.svc.ListAbsences(r.Context(), userID)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"user_id": userID, "absences": absences})
+}
+
+func (h *Handler) updateAbsence(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AbsenceID int64 `json:"absence_id"`
+		service.AbsenceUpdate
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AbsenceID == 0 {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "absence_id required")
+		return
+	}
+	absence, err := h.svc.UpdateAbsence(r.Context(), req.AbsenceID, req.AbsenceUpdate)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"absence": absence})
+}
+
+func (h *Handler) deleteAbsence(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AbsenceID int64 `json:"absence_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AbsenceID == 0 {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "absence_id required")
+		return
+	}
+	if err := h.svc.DeleteAbsence(r.Context(), req.AbsenceID); err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"absence_id": req.AbsenceID, "deleted": true})
+}
+
 func (h *Handler) createPR(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		PRID   string `json:"pull_request_id"`
-		PRName string `json:"pull_request_name"`
-		Author string `json:"author_id"`
+		PRID           string   `json:"pull_request_id"`
+		PRName         string   `json:"pull_request_name"`
+		Author         string   `json:"author_id"`
+		ChangedFiles   []string `json:"changed_files"`
+		RequiredSkills []string `json:"required_skills"`
+		Draft          bool     `json:"draft"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PRID == "" || req.PRName == "" || req.Author == "" {
 		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "pull_request_id, pull_request_name and author_id required")
 		return
 	}
-	pr, err := h.svc.CreatePR(r.Context(), req.PRID, req.PRName, req.Author)
+	pr, explanation, err := h.svc.CreatePRExplained(r.Context(), service.CreatePRRequest{
+		PullRequestID:   req.PRID,
+		PullRequestName: req.PRName,
+		AuthorID:        req.Author,
+		ChangedFiles:    req.ChangedFiles,
+		RequiredSkills:  req.RequiredSkills,
+		Draft:           req.Draft,
+		Explain:         wantsExplanation(r),
+	})
 	if err != nil {
 		handleSvcError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, map[string]any{"pr": pr})
+	setETag(w, pr)
+	resp := map[string]any{"pr": pr}
+	if explanation != nil {
+		resp["explanation"] = explanation
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (h *Handler) previewPR(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PRID           string   `json:"pull_request_id"`
+		PRName         string   `json:"pull_request_name"`
+		Author         string   `json:"author_id"`
+		ChangedFiles   []string `json:"changed_files"`
+		RequiredSkills []string `json:"required_skills"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Author == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "author_id required")
+		return
+	}
+	preview, err := h.svc.PreviewPR(r.Context(), service.CreatePRRequest{
+		PullRequestID:   req.PRID,
+		PullRequestName: req.PRName,
+		AuthorID:        req.Author,
+		ChangedFiles:    req.ChangedFiles,
+		RequiredSkills:  req.RequiredSkills,
+		Explain:         wantsExplanation(r),
+	})
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, preview)
 }
 
 func (h *Handler) mergePR(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		PRID string `json:"pull_request_id"`
+		PRID  string `json:"pull_request_id"`
+		Force bool   `json:"force"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PRID == "" {
 		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "pull_request_id required")
 		return
 	}
-	pr, err := h.svc.MergePR(r.Context(), req.PRID)
+	if req.Force && !h.isAdmin(r) {
+		writeError(w, http.StatusForbidden, apiErrors.Forbidden, "force merge requires a valid X-Admin-Token")
+		return
+	}
+	ifVersion, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	pr, err := h.svc.MergePR(r.Context(), req.PRID, ifVersion, req.Force)
 	if err != nil {
 		handleSvcError(w, err)
 		return
 	}
+	setETag(w, pr)
 	writeJSON(w, http.StatusOK, map[string]any{"pr": pr})
 }
 
@@ -139,17 +455,133 @@ func (h *Handler) reassign(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		PRID    string `json:"pull_request_id"`
 		OldUser string `json:"old_user_id"`
+		NewUser string `json:"new_user_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PRID == "" || req.OldUser == "" {
 		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "pull_request_id and old_user_id required")
 		return
 	}
-	pr, replacedBy, err := h.svc.ReassignReviewer(r.Context(), req.PRID, req.OldUser)
+	ifVersion, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	pr, replacedBy, explanation, err := h.svc.ReassignReviewerExplained(r.Context(), req.PRID, req.OldUser, req.NewUser, ifVersion, wantsExplanation(r))
 	if err != nil {
 		handleSvcError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"pr": pr, "replaced_by": replacedBy})
+	setETag(w, pr)
+	resp := map[string]any{"pr": pr, "replaced_by": replacedBy}
+	if explanation != nil {
+		resp["explanation"] = explanation
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) addReviewer(w http.ResponseWriter, r *http.Request) {
+	h.changeReviewer(w, r, h.svc.AddReviewer)
+}
+
+func (h *Handler) removeReviewer(w http.ResponseWriter, r *http.Request) {
+	h.changeReviewer(w, r, h.svc.RemoveReviewer)
+}
+
+func (h *Handler) changeReviewer(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, prID, userID string, ifVersion int64) (model.PullRequest, error)) {
+	var req struct {
+		PRID   string `json:"pull_request_id"`
+		UserID string `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PRID == "" || req.UserID == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "pull_request_id and user_id required")
+		return
+	}
+	ifVersion, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	pr, err := apply(r.Context(), req.PRID, req.UserID, ifVersion)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	setETag(w, pr)
+	writeJSON(w, http.StatusOK, map[string]any{"pr": pr})
+}
+
+func (h *Handler) review(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PRID     string `json:"pull_request_id"`
+		UserID   string `json:"user_id"`
+		Decision string `json:"decision"`
+		Comment  string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PRID == "" || req.UserID == "" || req.Decision == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "pull_request_id, user_id and decision required")
+		return
+	}
+	ifVersion, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	pr, err := h.svc.SubmitReview(r.Context(), model.Review{
+		PullRequestID: req.PRID,
+		UserID:        req.UserID,
+		Decision:      req.Decision,
+		Comment:       req.Comment,
+	}, ifVersion)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	setETag(w, pr)
+	writeJSON(w, http.StatusOK, map[string]any{"pr": pr})
+}
+
+func (h *Handler) markReady(w http.ResponseWriter, r *http.Request) {
+	h.transitionPR(w, r, h.svc.MarkReady)
+}
+
+func (h *Handler) closePR(w http.ResponseWriter, r *http.Request) {
+	h.transitionPR(w, r, h.svc.ClosePR)
+}
+
+func (h *Handler) reopenPR(w http.ResponseWriter, r *http.Request) {
+	h.transitionPR(w, r, h.svc.ReopenPR)
+}
+
+func (h *Handler) transitionPR(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, prID string, ifVersion int64) (model.PullRequest, error)) {
+	var req struct {
+		PRID string `json:"pull_request_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PRID == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "pull_request_id required")
+		return
+	}
+	ifVersion, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	pr, err := apply(r.Context(), req.PRID, ifVersion)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	setETag(w, pr)
+	writeJSON(w, http.StatusOK, map[string]any{"pr": pr})
+}
+
+func (h *Handler) getPRHistory(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		writeError(w, http.StatusBadRequest, apiErrors.InternalError, "pull_request_id required")
+		return
+	}
+	events, err := h.svc.GetPRHistory(r.Context(), prID)
+	if err != nil {
+		handleSvcError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"pull_request_id": prID, "events": events})
 }
 
 func (h *Handler) getUserPRs(w http.ResponseWriter, r *http.Request) {
@@ -175,6 +607,37 @@ func (h *Handler) getStats(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, stats)
 }
 
+func (h *Handler) isAdmin(r *http.Request) bool {
+	token := r.Header.Get("X-Admin-Token")
+	return h.adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1
+}
+
+// setETag exposes the PR version as a strong entity tag.
+func setETag(w http.ResponseWriter, pr model.PullRequest) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, pr.Version))
+}
+
+// ifMatchVersion reads the PR version expected by the If-Match header, 0 when the header is absent or "*".
+// It writes a 400 response and returns false if the header is not a single ETag issued by setETag.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	if tag == "" || tag == "*" {
+		return 0, true
+	}
+	version, err := strconv.ParseInt(strings.Trim(tag, `"`), 10, 64)
+	if err != nil || version <= 0 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		writeError(w, http.StatusBadRequest, apiErrors.InvalidArgument, "If-Match must be a single ETag returned for the PR")
+		return 0, false
+	}
+	return version, true
+}
+
+// wantsExplanation reports whether the client asked for an assignment explanation with ?explain=true.
+func wantsExplanation(r *http.Request) bool {
+	explain, _ := strconv.ParseBool(r.URL.Query().Get("explain"))
+	return explain
+}
+
 func writeJSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -187,6 +650,12 @@ func writeError(w http.ResponseWriter, code int, errCode apiErrors.ErrorCode, me
 	})
 }
 
+func writeErrorDetails(w http.ResponseWriter, code int, errCode apiErrors.ErrorCode, message string, details any) {
+	writeJSON(w, code, map[string]any{
+		"error": map[string]any{"code": errCode, "message": message, "details": details},
+	})
+}
+
 func handleSvcError(w http.ResponseWriter, err error) {
 	var e apiErrors.APIError
 	switch {
@@ -198,12 +667,27 @@ func handleSvcError(w http.ResponseWriter, err error) {
 			writeError(w, http.StatusConflict, e.Code, e.Message)
 		case apiErrors.PRAlreadyMerged:
 			writeError(w, http.StatusConflict, e.Code, e.Message)
+		case apiErrors.PRClosed, apiErrors.PRDraft, apiErrors.PRNotDraft, apiErrors.PRNotClosed:
+			writeError(w, http.StatusConflict, e.Code, e.Message)
 		case apiErrors.NotAssigned:
 			writeError(w, http.StatusConflict, e.Code, e.Message)
-		case apiErrors.NoCandidate:
+		case apiErrors.AlreadyAssigned, apiErrors.ReviewerIsAuthor, apiErrors.ReviewerNotInTeam, apiErrors.ReviewerInactive,
+			apiErrors.ReviewerAbsent:
+			writeError(w, http.StatusConflict, e.Code, e.Message)
+		case apiErrors.NoCandidate, apiErrors.CapacityExceeded:
+			writeError(w, http.StatusConflict, e.Code, e.Message)
+		case apiErrors.NotEnoughReviewers, apiErrors.NotEnoughSeniors:
+			writeError(w, http.StatusConflict, e.Code, e.Message)
+		case apiErrors.Conflict:
 			writeError(w, http.StatusConflict, e.Code, e.Message)
 		case apiErrors.NotFound:
 			writeError(w, http.StatusNotFound, e.Code, e.Message)
+		case apiErrors.InvalidArgument:
+			writeError(w, http.StatusBadRequest, e.Code, e.Message)
+		case apiErrors.MergeBlocked:
+			writeErrorDetails(w, http.StatusConflict, e.Code, e.Message, e.Details)
+		case apiErrors.PreconditionFailed:
+			writeError(w, http.StatusPreconditionFailed, e.Code, e.Message)
 		default:
 			writeError(w, http.StatusInternalServerError, apiErrors.InternalError, e.Message)
 		}
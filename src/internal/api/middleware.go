@@ -1,6 +1,13 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"io"
 	"log"
 	"net/http"
 	"time"
@@ -43,3 +50,121 @@ func Recoverer(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+const maxIdempotencyKeyLen = 255
+
+// replayedHeaders are the response headers stored and replayed for idempotent requests.
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// IdempotencyStore persists responses of requests sent with an Idempotency-Key.
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, rec model.IdempotencyRecord) (model.IdempotencyRecord, bool, error)
+	SaveIdempotentResponse(ctx context.Context, rec model.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, key, method, path string) error
+}
+
+// IdempotencyMiddleware replays the stored response of a POST retried with the same Idempotency-Key
+// for ttl after the first attempt. A key reused with a different body is rejected with 422.
+// Responses with 5xx status are not stored, so such requests can be retried.
+func IdempotencyMiddleware(st IdempotencyStore, ttl time.Duration, logger *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" || r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				writeError(w, http.StatusBadRequest, apiErrors.InvalidArgument, "Idempotency-Key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, apiErrors.InternalError, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+
+			rec := model.IdempotencyRecord{
+				Key:         key,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				RequestHash: hex.EncodeToString(sum[:]),
+				ExpiresAt:   time.Now().UTC().Add(ttl),
+			}
+			stored, reserved, err := st.ReserveIdempotencyKey(r.Context(), rec)
+			if err != nil {
+				logger.Error("idempotency: reserve key failed", zap.String("key", key), zap.Error(err))
+				writeError(w, http.StatusInternalServerError, apiErrors.InternalError, "failed to check Idempotency-Key")
+				return
+			}
+			if !reserved {
+				switch {
+				case stored.RequestHash != rec.RequestHash:
+					writeError(w, http.StatusUnprocessableEntity, apiErrors.IdempotencyKeyReused, "Idempotency-Key was already used with a different request body")
+				case stored.StatusCode == 0:
+					writeError(w, http.StatusConflict, apiErrors.Conflict, "request with this Idempotency-Key is still in progress")
+				default:
+					for name, value := range stored.Headers {
+						w.Header().Set(name, value)
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(stored.StatusCode)
+					_, _ = w.Write(stored.Body)
+				}
+				return
+			}
+
+			// The outcome is recorded even if the request context is already cancelled.
+			ctx := context.WithoutCancel(r.Context())
+			rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				if err := st.ReleaseIdempotencyKey(ctx, key, rec.Method, rec.Path); err != nil {
+					logger.Error("idempotency: release key failed", zap.String("key", key), zap.Error(err))
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+			if rw.status >= http.StatusInternalServerError {
+				return
+			}
+
+			rec.StatusCode = rw.status
+			rec.Body = rw.body.Bytes()
+			rec.Headers = make(map[string]string, len(replayedHeaders))
+			for _, name := range replayedHeaders {
+				if value := w.Header().Get(name); value != "" {
+					rec.Headers[name] = value
+				}
+			}
+			if err := st.SaveIdempotentResponse(ctx, rec); err != nil {
+				logger.Error("idempotency: save response failed", zap.String("key", key), zap.Error(err))
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// recordingWriter passes the response through and keeps a copy of its status and body.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
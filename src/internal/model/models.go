@@ -2,17 +2,42 @@ package model
 
 import "time"
 
+// User is a team member. MaxOpenReviews caps the OPEN PRs they review at once, nil means no limit.
 type User struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	TeamName       string `json:"team_name"`
+	IsActive       bool   `json:"is_active"`
+	Role           string `json:"role"`
+	MaxOpenReviews *int   `json:"max_open_reviews,omitempty"`
+}
+
+// Team member roles, ordered by seniority.
+const (
+	RoleMember = "member"
+	RoleSenior = "senior"
+	RoleLead   = "lead"
+)
+
+// IsSenior reports whether role is senior or above.
+func IsSenior(role string) bool {
+	return role == RoleSenior || role == RoleLead
+}
+
+// Absence is an out-of-office period, the user is not assigned reviews while it covers now.
+type Absence struct {
+	AbsenceID int64     `json:"absence_id"`
+	UserID    string    `json:"user_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason"`
 }
 
 type TeamMember struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
+	Role     string `json:"role"`
 }
 
 type Team struct {
@@ -20,14 +45,173 @@ type Team struct {
 	Members  []TeamMember `json:"members"`
 }
 
+// TeamSettings holds the per-team reviewer assignment policy.
+// An empty Strategy means the deployment default. FallbackTeams are
+// asked in order when the team itself lacks active reviewers.
+// RequiredApprovals gates merging of the team's PRs on top of the global requirement.
+// AntiAffinityWindow is the number of the author's latest PRs whose reviewers are
+// avoided on the next one, zero disables it. MinSeniorReviewers reviewers of every PR must
+// be senior or lead, SeniorPolicy tells whether falling short fails the assignment or is only reported.
+type TeamSettings struct {
+	TeamName           string   `json:"team_name"`
+	ReviewersCount     int      `json:"reviewers_count"`
+	MinReviewers       int      `json:"min_reviewers"`
+	Strategy           string   `json:"strategy"`
+	FallbackTeams      []string `json:"fallback_teams"`
+	RequiredApprovals  int      `json:"required_approvals"`
+	AntiAffinityWindow int      `json:"anti_affinity_window"`
+	MinSeniorReviewers int      `json:"min_senior_reviewers"`
+	SeniorPolicy       string   `json:"senior_policy"`
+}
+
+// PairCount is how many PRs of AuthorID ReviewerID was assigned to.
+type PairCount struct {
+	AuthorID   string `json:"author_id"`
+	ReviewerID string `json:"reviewer_id"`
+	Count      int    `json:"count"`
+}
+
+// CodeOwnerRule routes changed paths matching Pattern to a team or a single user, like a CODEOWNERS line.
+// Rules are ordered and the last rule matching a path owns it.
+type CodeOwnerRule struct {
+	Pattern  string `json:"pattern"`
+	TeamName string `json:"team_name,omitempty"`
+	UserID   string `json:"user_id,omitempty"`
+}
+
+const (
+	StatusDraft  = "DRAFT"
+	StatusOpen   = "OPEN"
+	StatusMerged = "MERGED"
+	StatusClosed = "CLOSED"
+)
+
 type PullRequest struct {
-	PullRequestID   string     `json:"pull_request_id"`
-	PullRequestName string     `json:"pull_request_name"`
-	AuthorID        string     `json:"author_id"`
-	Status          string     `json:"status"`
-	Assigned        []string   `json:"assigned_reviewers"`
-	CreatedAt       time.Time  `json:"createdAt,omitempty"`
-	MergedAt        *time.Time `json:"mergedAt,omitempty"`
+	PullRequestID   string          `json:"pull_request_id"`
+	PullRequestName string          `json:"pull_request_name"`
+	AuthorID        string          `json:"author_id"`
+	Status          string          `json:"status"`
+	Assigned        []string        `json:"assigned_reviewers"`
+	Fallback        []string        `json:"fallback_reviewers,omitempty"`
+	Reviewers       []ReviewerState `json:"reviewers"`
+	CreatedAt       time.Time       `json:"createdAt,omitempty"`
+	MergedAt        *time.Time      `json:"mergedAt,omitempty"`
+	ClosedAt        *time.Time      `json:"closedAt,omitempty"`
+	ForceMerged     bool            `json:"force_merged,omitempty"`
+	ChangedFiles    []string        `json:"changed_files,omitempty"`
+	RequiredSkills  []string        `json:"required_skills,omitempty"`
+	// UncoveredSkills lists the required skills no reviewer has, it is reported by the write that assigned them.
+	UncoveredSkills []string `json:"uncovered_skills,omitempty"`
+	// MissingSeniors is how many senior reviewers the team policy lacks, it is reported by the write that assigned them.
+	MissingSeniors int `json:"missing_seniors,omitempty"`
+	// Assignment describes how Assigned was picked by the current write, it is only recorded in the history.
+	Assignment AssignmentInfo `json:"-"`
+	// Version is bumped by every write to the PR and guards against lost updates.
+	Version int64 `json:"-"`
+}
+
+const (
+	ReviewPending          = "PENDING"
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+)
+
+// Review is a decision left on a PR by one of its assigned reviewers.
+type Review struct {
+	ReviewID      int64     `json:"review_id"`
+	PullRequestID string    `json:"pull_request_id"`
+	UserID        string    `json:"user_id"`
+	Decision      string    `json:"decision"`
+	Comment       string    `json:"comment"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ReviewerState is the latest decision of an assigned reviewer, PENDING until they review.
+type ReviewerState struct {
+	UserID     string     `json:"user_id"`
+	State      string     `json:"state"`
+	Comment    string     `json:"comment,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once completed, its response.
+// StatusCode is zero while the original request is still being processed.
+type IdempotencyRecord struct {
+	Key         string
+	Method      string
+	Path        string
+	RequestHash string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// AssignmentInfo tells how reviewers were picked, it is recorded with assignment events.
+// Seed is the per-PR seed the strategy made its random choices with, zero for manual picks.
+type AssignmentInfo struct {
+	Strategy string `json:"strategy"`
+	Seed     int64  `json:"seed,string,omitempty"`
+}
+
+// AssignedManually is the AssignmentInfo strategy of reviewers requested explicitly by a client.
+const AssignedManually = "manual"
+
+// PR history event types.
+const (
+	EventCreated            = "CREATED"
+	EventReviewersAssigned  = "REVIEWERS_ASSIGNED"
+	EventReviewerReassigned = "REVIEWER_REASSIGNED"
+	EventReviewerAdded      = "REVIEWER_ADDED"
+	EventReviewerRemoved    = "REVIEWER_REMOVED"
+	EventReviewed           = "REVIEWED"
+	EventStatusChanged      = "STATUS_CHANGED"
+	EventMerged             = "MERGED"
+)
+
+// PREvent is an entry of the append-only PR history. Data holds event specific fields.
+type PREvent struct {
+	EventID       int64          `json:"event_id"`
+	PullRequestID string         `json:"pull_request_id"`
+	Type          string         `json:"type"`
+	Data          map[string]any `json:"data"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// PRTransition moves a PR from one status to another under the PR lock.
+// ReleaseReviewers drops the current reviewers before Assigned (with Fallback
+// marking fallback reviewers) are added. A non-zero Version must match the stored one.
+type PRTransition struct {
+	PullRequestID    string
+	From             []string
+	To               string
+	Version          int64
+	ReleaseReviewers bool
+	Assigned         []string
+	Fallback         []string
+	Assignment       AssignmentInfo
+}
+
+// ReviewerSwap replaces OldUserID with NewUserID on a PR. Version is the PR version the
+// swap was planned against; bulk reassignment rejects swaps for PRs that have moved on.
+type ReviewerSwap struct {
+	PullRequestID string
+	OldUserID     string
+	NewUserID     string
+	IsFallback    bool
+	Version       int64
+	Assignment    AssignmentInfo
+}
+
+// ReviewerChange adds or removes a single reviewer of an OPEN PR at the given Version.
+// On removal MinReviewers is the number of reviewers that must remain assigned.
+type ReviewerChange struct {
+	PullRequestID string
+	UserID        string
+	IsFallback    bool
+	MinReviewers  int
+	Version       int64
 }
 
 type PullRequestShort struct {
@@ -42,6 +226,12 @@ type AppError string
 func (e AppError) Error() string { return string(e) }
 
 const (
-	ErrTeamExists = AppError("TEAM_EXISTS")
-	ErrNotFound   = AppError("NOT_FOUND")
+	ErrTeamExists         = AppError("TEAM_EXISTS")
+	ErrNotFound           = AppError("NOT_FOUND")
+	ErrConflict           = AppError("CONFLICT")
+	ErrPRMerged           = AppError("PR_MERGED")
+	ErrPRNotOpen          = AppError("PR_NOT_OPEN")
+	ErrNotAssigned        = AppError("NOT_ASSIGNED")
+	ErrAlreadyAssigned    = AppError("ALREADY_ASSIGNED")
+	ErrNotEnoughReviewers = AppError("NOT_ENOUGH_REVIEWERS")
 )
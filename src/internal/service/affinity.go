@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/store"
+	"sort"
+)
+
+// AntiAffinityStrategy spreads reviews across the team: candidates who reviewed fewer of the
+// author's latest window PRs go first, the wrapped strategy chooses among equally paired ones.
+// The PR being assigned is never counted against its own reviewers.
+type AntiAffinityStrategy struct {
+	inner  AssignmentStrategy
+	repo   store.Repository
+	window int
+}
+
+func NewAntiAffinityStrategy(inner AssignmentStrategy, repo store.Repository, window int) *AntiAffinityStrategy {
+	return &AntiAffinityStrategy{inner: inner, repo: repo, window: window}
+}
+
+func (s *AntiAffinityStrategy) Name() string { return s.inner.Name() }
+
+func (s *AntiAffinityStrategy) Pick(ctx context.Context, req AssignmentRequest) ([]string, error) {
+	if len(req.Candidates) == 0 || req.Count <= 0 || req.Author.UserID == "" {
+		return s.inner.Pick(ctx, req)
+	}
+	pairs, err := s.pairs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var levels []int
+	for _, id := range req.Candidates {
+		levels = append(levels, pairs[id])
+	}
+	sort.Ints(levels)
+
+	var picked []string
+	for i, n := range levels {
+		if len(picked) >= req.Count {
+			break
+		}
+		if i > 0 && levels[i-1] == n {
+			continue
+		}
+		var group []string
+		for _, id := range req.Candidates {
+			if pairs[id] == n {
+				group = append(group, id)
+			}
+		}
+		sub := req.stage("anti_affinity", i)
+		sub.Candidates, sub.Count = group, req.Count-len(picked)
+		got, err := s.inner.Pick(ctx, sub)
+		if err != nil {
+			return nil, err
+		}
+		picked = append(picked, got...)
+	}
+	return picked, nil
+}
+
+// Inputs reports how many of the author's recent PRs every candidate reviewed next to the inputs of the wrapped strategy.
+func (s *AntiAffinityStrategy) Inputs(ctx context.Context, req AssignmentRequest) (map[string]any, error) {
+	inputs := map[string]any{}
+	if r, ok := s.inner.(InputReporter); ok {
+		var err error
+		if inputs, err = r.Inputs(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	if req.Author.UserID == "" {
+		return inputs, nil
+	}
+	pairs, err := s.pairs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	recent := make(map[string]int, len(req.Candidates))
+	for _, id := range req.Candidates {
+		recent[id] = pairs[id]
+	}
+	inputs["recent_pairings"] = recent
+	inputs["anti_affinity_window"] = s.window
+	return inputs, nil
+}
+
+func (s *AntiAffinityStrategy) pairs(ctx context.Context, req AssignmentRequest) (map[string]int, error) {
+	return s.repo.GetRecentPairCounts(ctx, req.Author.UserID, req.PR.PullRequestID, s.window)
+}
+
+// FairnessReport is the author-reviewer pair matrix of a team: Pairs[author][reviewer] is the number
+// of PRs of author reviewer was assigned to. Every member has a row with every other member, reviewers
+// from other teams appear where they reviewed.
+type FairnessReport struct {
+	TeamName string                    `json:"team_name"`
+	Members  []string                  `json:"members"`
+	Pairs    map[string]map[string]int `json:"pairs"`
+}
+
+func (s *Service) GetFairnessReport(ctx context.Context, teamName string) (FairnessReport, error) {
+	team, err := s.GetTeam(ctx, teamName)
+	if err != nil {
+		return FairnessReport{}, err
+	}
+	counts, err := s.repo.GetTeamPairCounts(ctx, teamName)
+	if err != nil {
+		return FairnessReport{}, err
+	}
+
+	report := FairnessReport{TeamName: teamName, Members: []string{}, Pairs: make(map[string]map[string]int, len(team.Members))}
+	for _, m := range team.Members {
+		report.Members = append(report.Members, m.UserID)
+	}
+	for _, author := range report.Members {
+		row := make(map[string]int, len(report.Members)-1)
+		for _, reviewer := range report.Members {
+			if reviewer != author {
+				row[reviewer] = 0
+			}
+		}
+		report.Pairs[author] = row
+	}
+	for _, pc := range counts {
+		row, ok := report.Pairs[pc.AuthorID]
+		if !ok {
+			row = map[string]int{}
+			report.Pairs[pc.AuthorID] = row
+		}
+		row[pc.ReviewerID] = pc.Count
+	}
+	return report, nil
+}
+
+// assignmentStrategy is the strategy picking reviewers of pr under settings: the team's strategy,
+// avoiding recent author-reviewer pairs if the team has an anti-affinity window, preferring
+// reviewers with the skills pr requires and picking the seniors the team requires first.
+func (s *Service) assignmentStrategy(settings model.TeamSettings, pr model.PullRequest) AssignmentStrategy {
+	strategy := s.strategyFor(settings.Strategy)
+	if settings.AntiAffinityWindow > 0 {
+		strategy = NewAntiAffinityStrategy(strategy, s.repo, settings.AntiAffinityWindow)
+	}
+	if len(pr.RequiredSkills) > 0 {
+		strategy = NewSkillMatchingStrategy(strategy, s.repo)
+	}
+	if settings.MinSeniorReviewers > 0 {
+		strategy = NewSeniorityStrategy(strategy, s.repo)
+	}
+	return strategy
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAntiAffinityStrategy_PrefersFreshPairs(t *testing.T) {
+	mockRepo := new(MockRepositories)
+	mockRepo.On("GetRecentPairCounts", mock.Anything, "u1", "pr1", 5).Return(map[string]int{"u2": 3, "u4": 1}, nil)
+	strategy := NewAntiAffinityStrategy(NewRoundRobinStrategy(), mockRepo, 5)
+
+	picked, err := strategy.Pick(context.Background(), AssignmentRequest{
+		PR:         model.PullRequest{PullRequestID: "pr1", AuthorID: "u1"},
+		Author:     model.User{UserID: "u1"},
+		Candidates: []string{"u2", "u3", "u4", "u5"},
+		Count:      3,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3", "u5", "u4"}, picked, "u2 reviewed most of u1's recent PRs and goes last")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAntiAffinityStrategy_Inputs(t *testing.T) {
+	mockRepo := new(MockRepositories)
+	mockRepo.On("GetRecentPairCounts", mock.Anything, "u1", "pr1", 2).Return(map[string]int{"u2": 2}, nil)
+	strategy := NewAntiAffinityStrategy(NewRandomStrategy(), mockRepo, 2)
+
+	inputs, err := strategy.Inputs(context.Background(), AssignmentRequest{
+		PR:         model.PullRequest{PullRequestID: "pr1"},
+		Author:     model.User{UserID: "u1"},
+		Candidates: []string{"u2", "u3"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"u2": 2, "u3": 0}, inputs["recent_pairings"])
+	assert.Equal(t, 2, inputs["anti_affinity_window"])
+}
+
+func TestCreatePR_AntiAffinityWindow(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 1, AntiAffinityWindow: 3}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3"}, nil)
+	mockRepo.On("GetRecentPairCounts", mock.Anything, "u1", "pr1", 3).Return(map[string]int{"u2": 3, "u3": 1}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(context.Background(), "pr1", "Spread reviews", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3"}, pr.Assigned)
+	assert.Equal(t, StrategyRandom, pr.Assignment.Strategy)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetFairnessReport(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(model.Team{TeamName: "backend", Members: []model.TeamMember{
+		{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"},
+	}}, nil)
+	mockRepo.On("GetTeamPairCounts", mock.Anything, "backend").Return([]model.PairCount{
+		{AuthorID: "u1", ReviewerID: "u2", Count: 4},
+		{AuthorID: "u1", ReviewerID: "u9", Count: 1},
+		{AuthorID: "u3", ReviewerID: "u1", Count: 2},
+	}, nil)
+
+	report, err := service.GetFairnessReport(context.Background(), "backend")
+
+	assert.NoError(t, err)
+	assert.Equal(t, FairnessReport{
+		TeamName: "backend",
+		Members:  []string{"u1", "u2", "u3"},
+		Pairs: map[string]map[string]int{
+			"u1": {"u2": 4, "u3": 0, "u9": 1},
+			"u2": {"u1": 0, "u3": 0},
+			"u3": {"u1": 2, "u2": 0},
+		},
+	}, report)
+}
+
+func TestDeactivateTeamUsers_AntiAffinityWindow(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.seeds = NewSeedSource(1)
+
+	team := model.Team{TeamName: "backend", Members: []model.TeamMember{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}}}
+	var prs []model.PullRequest
+	for i := 0; i < 6; i++ {
+		prs = append(prs, model.PullRequest{PullRequestID: fmt.Sprintf("pr%d", i), AuthorID: "u1", Status: model.StatusOpen, Assigned: []string{"u2"}})
+	}
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(team, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 1, AntiAffinityWindow: 3}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "").Return([]string{"u1", "u2", "u3", "u4"}, nil)
+	mockRepo.On("GetOpenReviewCounts", mock.Anything, []string{"u1", "u3", "u4"}).Return(map[string]int{}, nil)
+	mockRepo.On("GetOpenPRsForReviewers", mock.Anything, []string{"u2"}).Return(prs, nil)
+	mockRepo.On("GetRecentPairCounts", mock.Anything, "u1", mock.Anything, 3).Return(map[string]int{"u3": 2}, nil)
+	mockRepo.On("DeactivateUsersAndReassign", mock.Anything, "backend", []string{"u2"}, mock.Anything).Return(nil)
+
+	result, err := service.DeactivateTeamUsers(context.Background(), "backend", []string{"u2"})
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Reassigned, len(prs))
+	for _, r := range result.Reassigned {
+		assert.Equal(t, "u4", r.NewReviewerID, "u3 reviewed u1's recent PRs and should not take over %s", r.PullRequestID)
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+)
+
+// What to do when a team cannot fill its reviewer slots because its members reached their max_open_reviews.
+const (
+	CapacityOverflowLeastLoaded = "least_loaded" // assign the least loaded members anyway
+	CapacityOverflowFallback    = "fallback"     // go on with the fallback teams
+	CapacityOverflowFail        = "fail"         // reject with CAPACITY_EXCEEDED
+)
+
+// ValidCapacityOverflow reports whether mode is one of the CapacityOverflow modes.
+func ValidCapacityOverflow(mode string) bool {
+	switch mode {
+	case CapacityOverflowLeastLoaded, CapacityOverflowFallback, CapacityOverflowFail:
+		return true
+	}
+	return false
+}
+
+// SetUserMaxOpenReviews limits the OPEN PRs a user reviews at once, nil removes the limit.
+// Reviews the user already has are kept even if they exceed the new limit.
+func (s *Service) SetUserMaxOpenReviews(ctx context.Context, userID string, maxOpen *int) (model.User, error) {
+	if maxOpen != nil && *maxOpen < 0 {
+		return model.User{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "max_open_reviews must not be negative"}
+	}
+	u, err := s.repo.SetUserMaxOpenReviews(ctx, userID, maxOpen)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return model.User{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "user not found"}
+		}
+		return model.User{}, err
+	}
+	return u, nil
+}
+
+// withoutFull splits candidates into those with spare capacity and those at capacity.
+// The latter are recorded in excluded if it is set.
+func (s *Service) withoutFull(ctx context.Context, candidates []string, excluded map[string]string) ([]string, []string, error) {
+	if len(candidates) == 0 {
+		return candidates, nil, nil
+	}
+	full, err := s.repo.GetUsersAtCapacity(ctx, candidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(full) == 0 {
+		return candidates, nil, nil
+	}
+	if excluded != nil {
+		for _, id := range full {
+			excluded[id] = ExcludedAtCapacity
+		}
+	}
+	return without(candidates, full), full, nil
+}
+
+// overflow handles count reviewer slots of team left empty because its members in full are at capacity.
+// Depending on the configured mode it picks the least loaded of them, fails with CAPACITY_EXCEEDED
+// or picks nobody, leaving the slots to the fallback teams. The overflow pool is recorded in ex if it is set,
+// excluded must not list the members in full.
+func (s *Service) overflow(ctx context.Context, base AssignmentRequest, full []string, count int,
+	ex *Explanation, team string, excluded map[string]string) ([]string, error) {
+	if len(full) == 0 || count <= 0 {
+		return nil, nil
+	}
+	switch s.capacityOverflow {
+	case CapacityOverflowFail:
+		return nil, apiErrors.APIError{
+			Code:    apiErrors.CapacityExceeded,
+			Message: fmt.Sprintf("team %q has no reviewer below their open review limit", team),
+		}
+	case CapacityOverflowLeastLoaded:
+		var strategy AssignmentStrategy = NewLeastLoadedStrategy(s.repo)
+		if len(base.RequiredSkills) > 0 {
+			strategy = NewSkillMatchingStrategy(strategy, s.repo)
+		}
+		if base.MinSeniors > 0 {
+			strategy = NewSeniorityStrategy(strategy, s.repo)
+		}
+		req := base.stage("overflow", 0)
+		req.Candidates, req.Count = full, count
+		picked, err := s.pick(ctx, strategy, req, ex, team, false, excluded)
+		if err != nil {
+			return nil, err
+		}
+		if ex != nil {
+			ex.Pools[len(ex.Pools)-1].Overflow = true
+		}
+		return picked, nil
+	default:
+		return nil, nil
+	}
+}
+
+// checkCapacity rejects userID as a reviewer if they are at capacity.
+func (s *Service) checkCapacity(ctx context.Context, userID string) error {
+	_, full, err := s.withoutFull(ctx, []string{userID}, nil)
+	if err != nil {
+		return err
+	}
+	if len(full) > 0 {
+		return apiErrors.APIError{Code: apiErrors.CapacityExceeded, Message: "user reached their open review limit"}
+	}
+	return nil
+}
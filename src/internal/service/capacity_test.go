@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// dropCalls removes the expectations of method, e.g. the defaults of createTestService.
+func dropCalls(mockRepo *MockRepositories, method string) {
+	var calls []*mock.Call
+	for _, c := range mockRepo.ExpectedCalls {
+		if c.Method != method {
+			calls = append(calls, c)
+		}
+	}
+	mockRepo.ExpectedCalls = calls
+}
+
+// atCapacity replaces the default answer of the mock: the users in full are at capacity.
+func atCapacity(mockRepo *MockRepositories, full ...string) {
+	dropCalls(mockRepo, "GetUsersAtCapacity")
+
+	call := mockRepo.On("GetUsersAtCapacity", mock.Anything, mock.Anything)
+	call.Run(func(args mock.Arguments) {
+		var out []string
+		for _, id := range args.Get(1).([]string) {
+			if contains(full, id) {
+				out = append(out, id)
+			}
+		}
+		call.ReturnArguments = mock.Arguments{out, nil}
+	})
+}
+
+func capacityAuthor(mockRepo *MockRepositories, settings model.TeamSettings, members []string) {
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: settings.TeamName, IsActive: true}, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, settings.TeamName).Return(settings, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, settings.TeamName, "u1").Return(members, nil)
+}
+
+func TestCreatePR_SkipsReviewersAtCapacity(t *testing.T) {
+	service, mockRepo := createTestService()
+	atCapacity(mockRepo, "u2")
+	capacityAuthor(mockRepo, model.TeamSettings{TeamName: "backend", ReviewersCount: 2}, []string{"u2", "u3", "u4"})
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(context.Background(), "pr1", "Busy team", "u1")
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"u3", "u4"}, pr.Assigned)
+}
+
+func TestCreatePR_CapacityOverflowFallback(t *testing.T) {
+	service, mockRepo := createTestService()
+	atCapacity(mockRepo, "u2", "u3")
+	capacityAuthor(mockRepo, model.TeamSettings{TeamName: "backend", ReviewersCount: 1, FallbackTeams: []string{"platform"}}, []string{"u2", "u3"})
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "platform", "u1").Return([]string{"u7"}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(context.Background(), "pr1", "Busy team", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u7"}, pr.Assigned)
+	assert.Equal(t, []string{"u7"}, pr.Fallback)
+}
+
+func TestCreatePR_CapacityOverflowLeastLoaded(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.capacityOverflow = CapacityOverflowLeastLoaded
+	atCapacity(mockRepo, "u2", "u3")
+	capacityAuthor(mockRepo, model.TeamSettings{TeamName: "backend", ReviewersCount: 2, FallbackTeams: []string{"platform"}}, []string{"u2", "u3", "u4"})
+	mockRepo.On("GetOpenReviewCounts", mock.Anything, []string{"u2", "u3"}).Return(map[string]int{"u2": 5, "u3": 3}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(context.Background(), "pr1", "Busy team", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u4", "u3"}, pr.Assigned)
+	assert.Empty(t, pr.Fallback)
+	mockRepo.AssertNotCalled(t, "GetActiveTeamMembersExcept", mock.Anything, "platform", "u1")
+}
+
+func TestCreatePR_CapacityOverflowFail(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.capacityOverflow = CapacityOverflowFail
+	atCapacity(mockRepo, "u2", "u3")
+	capacityAuthor(mockRepo, model.TeamSettings{TeamName: "backend", ReviewersCount: 1}, []string{"u2", "u3"})
+
+	_, err := service.CreatePR(context.Background(), "pr1", "Busy team", "u1")
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.CapacityExceeded, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "CreatePRWithReviewers", mock.Anything, mock.Anything)
+}
+
+func TestReassignReviewer_CapacityOverflowFail(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.capacityOverflow = CapacityOverflowFail
+	atCapacity(mockRepo, "u4")
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR(), nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(model.User{UserID: "u2", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 2}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u2").Return([]string{"u1", "u3", "u4"}, nil)
+
+	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.CapacityExceeded, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "ReplaceReviewer", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAddReviewer_AtCapacity(t *testing.T) {
+	service, mockRepo := createTestService()
+	atCapacity(mockRepo, "u4")
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR(), nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u4").Return(model.User{UserID: "u4", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 2}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3", "u4"}, nil)
+
+	_, err := service.AddReviewer(context.Background(), "pr1", "u4", 0)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.CapacityExceeded, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "AssignReviewer", mock.Anything, mock.Anything)
+}
+
+func TestSetUserMaxOpenReviews(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	limit := 3
+	mockRepo.On("SetUserMaxOpenReviews", mock.Anything, "u1", &limit).Return(model.User{UserID: "u1", MaxOpenReviews: &limit}, nil)
+
+	u, err := service.SetUserMaxOpenReviews(context.Background(), "u1", &limit)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, *u.MaxOpenReviews)
+
+	negative := -1
+	_, err = service.SetUserMaxOpenReviews(context.Background(), "u1", &negative)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.InvalidArgument, apiErr.Code)
+	mockRepo.AssertNumberOfCalls(t, "SetUserMaxOpenReviews", 1)
+}
+
+func TestDeactivateTeamUsers_RespectsCapacity(t *testing.T) {
+	capped := FailedReassignment{PullRequestID: "pr2", Code: apiErrors.CapacityExceeded, Message: `team "backend" has no reviewer below their open review limit`}
+	noCandidate := FailedReassignment{PullRequestID: "pr2", Code: apiErrors.NoCandidate, Message: "no active replacement candidate for u2"}
+	cases := []struct {
+		mode   string
+		swaps  int
+		failed []FailedReassignment
+	}{
+		{mode: CapacityOverflowFallback, swaps: 1, failed: []FailedReassignment{noCandidate}},
+		{mode: CapacityOverflowFail, swaps: 1, failed: []FailedReassignment{capped}},
+		{mode: CapacityOverflowLeastLoaded, swaps: 2, failed: []FailedReassignment{}},
+	}
+	for _, c := range cases {
+		t.Run(c.mode, func(t *testing.T) {
+			service, mockRepo := createTestService()
+			service.capacityOverflow = c.mode
+			dropCalls(mockRepo, "GetMaxOpenReviews")
+
+			team := model.Team{TeamName: "backend", Members: []model.TeamMember{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}}}
+			prs := []model.PullRequest{
+				{PullRequestID: "pr1", AuthorID: "u1", Status: model.StatusOpen, Assigned: []string{"u2"}},
+				{PullRequestID: "pr2", AuthorID: "u1", Status: model.StatusOpen, Assigned: []string{"u2"}},
+			}
+			mockRepo.On("GetTeam", mock.Anything, "backend").Return(team, nil)
+			mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+			mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "").Return([]string{"u1", "u2", "u3"}, nil)
+			mockRepo.On("GetOpenReviewCounts", mock.Anything, []string{"u1", "u3"}).Return(map[string]int{"u3": 1}, nil)
+			// u3 is the only candidate and has room for one more review
+			mockRepo.On("GetMaxOpenReviews", mock.Anything, []string{"u1", "u3"}).Return(map[string]int{"u3": 2}, nil)
+			mockRepo.On("GetOpenPRsForReviewers", mock.Anything, []string{"u2"}).Return(prs, nil)
+			mockRepo.On("DeactivateUsersAndReassign", mock.Anything, "backend", []string{"u2"}, mock.MatchedBy(func(swaps []model.ReviewerSwap) bool {
+				return len(swaps) == c.swaps && swaps[0].PullRequestID == "pr1" && swaps[0].NewUserID == "u3"
+			})).Return(nil)
+
+			result, err := service.DeactivateTeamUsers(context.Background(), "backend", []string{"u2"})
+
+			assert.NoError(t, err)
+			assert.Len(t, result.Reassigned, c.swaps)
+			assert.Equal(t, c.failed, result.Failed)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+)
+
+// Reasons a team member was not a candidate for a pick.
+const (
+	ExcludedAuthor          = "author"
+	ExcludedReplaced        = "replaced"
+	ExcludedAlreadyAssigned = "already_assigned"
+	ExcludedInactive        = "inactive"
+	ExcludedOutOfOffice     = "out_of_office"
+	ExcludedAtCapacity      = "at_capacity"
+)
+
+// Explanation tells why reviewers were picked: the teams considered, who was excluded and why,
+// and the strategy with the inputs each pick was based on. Seed is the seed of the random choices,
+// Owners the code owner rules matched by the changed files.
+type Explanation struct {
+	Strategy  string          `json:"strategy"`
+	Seed      int64           `json:"seed,string"`
+	Requested int             `json:"requested"`
+	Owners    []OwnerMatch    `json:"owners,omitempty"`
+	Pools     []CandidatePool `json:"pools"`
+	Picked    []string        `json:"picked"`
+}
+
+// CandidatePool is a team considered for a pick. Owning teams of the changed files are considered
+// first, then the author's team and its fallback teams. An Overflow pool holds members of the
+// author's team picked although they are at capacity.
+type CandidatePool struct {
+	Team       string         `json:"team"`
+	Fallback   bool           `json:"fallback"`
+	Owner      bool           `json:"owner,omitempty"`
+	Overflow   bool           `json:"overflow,omitempty"`
+	Candidates []string       `json:"candidates"`
+	Excluded   []Exclusion    `json:"excluded"`
+	Inputs     map[string]any `json:"inputs,omitempty"`
+	Picked     []string       `json:"picked"`
+}
+
+type Exclusion struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+func newExplanation(strategy string, requested int) *Explanation {
+	return &Explanation{Strategy: strategy, Requested: requested, Pools: []CandidatePool{}, Picked: []string{}}
+}
+
+// pick runs the strategy on req and, if ex is set, records the pool of team together with
+// the strategy inputs. excluded maps the users filtered out before the pick to the reason.
+func (s *Service) pick(ctx context.Context, strategy AssignmentStrategy, req AssignmentRequest,
+	ex *Explanation, team string, fallback bool, excluded map[string]string) ([]string, error) {
+	var pool CandidatePool
+	if ex != nil {
+		var err error
+		if pool, err = s.explainPool(ctx, team, fallback, req.Candidates, excluded); err != nil {
+			return nil, err
+		}
+		if r, ok := strategy.(InputReporter); ok && len(req.Candidates) > 0 {
+			if pool.Inputs, err = r.Inputs(ctx, req); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	picked, err := strategy.Pick(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ex != nil {
+		pool.Picked = append([]string{}, picked...)
+		ex.Pools = append(ex.Pools, pool)
+		ex.Picked = append(ex.Picked, picked...)
+	}
+	return picked, nil
+}
+
+// explainPool sorts the members of team into candidates and excluded users with the reason they were left out.
+func (s *Service) explainPool(ctx context.Context, team string, fallback bool, candidates []string, excluded map[string]string) (CandidatePool, error) {
+	pool := CandidatePool{
+		Team:       team,
+		Fallback:   fallback,
+		Candidates: append([]string{}, candidates...),
+		Excluded:   []Exclusion{},
+	}
+	t, err := s.repo.GetTeam(ctx, team)
+	if err != nil {
+		return CandidatePool{}, err
+	}
+	available, err := s.repo.GetActiveTeamMembersExcept(ctx, team, "")
+	if err != nil {
+		return CandidatePool{}, err
+	}
+	for _, m := range t.Members {
+		if contains(candidates, m.UserID) {
+			continue
+		}
+		reason := excluded[m.UserID]
+		switch {
+		case reason != "":
+		case !m.IsActive:
+			reason = ExcludedInactive
+		case !contains(available, m.UserID):
+			reason = ExcludedOutOfOffice
+		default:
+			continue
+		}
+		pool.Excluded = append(pool.Excluded, Exclusion{UserID: m.UserID, Reason: reason})
+	}
+	return pool, nil
+}
+
+// exclusions maps the author and the already assigned reviewers of pr to their exclusion reason.
+func exclusions(pr model.PullRequest, assigned []string) map[string]string {
+	out := make(map[string]string, len(assigned)+1)
+	for _, u := range assigned {
+		out[u] = ExcludedAlreadyAssigned
+	}
+	out[pr.AuthorID] = ExcludedAuthor
+	return out
+}
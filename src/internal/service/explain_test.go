@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreatePRExplained_ReportsPoolAndInputs(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.strategy = NewLeastLoadedStrategy(mockRepo)
+
+	team := model.Team{TeamName: "backend", Members: []model.TeamMember{
+		{UserID: "u1", IsActive: true},
+		{UserID: "u2", IsActive: true},
+		{UserID: "u3", IsActive: true},
+		{UserID: "u4", IsActive: false},
+		{UserID: "u5", IsActive: true},
+	}}
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 1}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3"}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "").Return([]string{"u1", "u2", "u3"}, nil)
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(team, nil)
+	mockRepo.On("GetOpenReviewCounts", mock.Anything, []string{"u2", "u3"}).Return(map[string]int{"u2": 4}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	pr, ex, err := service.CreatePRExplained(context.Background(), CreatePRRequest{
+		PullRequestID:   "pr1",
+		PullRequestName: "Explained PR",
+		AuthorID:        "u1",
+		Explain:         true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3"}, pr.Assigned)
+	if assert.NotNil(t, ex) && assert.Len(t, ex.Pools, 1) {
+		assert.Equal(t, StrategyLeastLoaded, ex.Strategy)
+		assert.Equal(t, []string{"u3"}, ex.Picked)
+		pool := ex.Pools[0]
+		assert.Equal(t, []string{"u2", "u3"}, pool.Candidates)
+		assert.Equal(t, []Exclusion{
+			{UserID: "u1", Reason: ExcludedAuthor},
+			{UserID: "u4", Reason: ExcludedInactive},
+			{UserID: "u5", Reason: ExcludedOutOfOffice},
+		}, pool.Excluded)
+		assert.Equal(t, map[string]any{"open_reviews": map[string]int{"u2": 4, "u3": 0}}, pool.Inputs)
+	}
+}
+
+func TestCreatePRExplained_OffByDefault(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3"}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	_, ex, err := service.CreatePRExplained(context.Background(), CreatePRRequest{PullRequestID: "pr1", PullRequestName: "PR", AuthorID: "u1"})
+
+	assert.NoError(t, err)
+	assert.Nil(t, ex)
+	mockRepo.AssertNotCalled(t, "GetTeam", mock.Anything, mock.Anything)
+}
+
+func TestReassignReviewerExplained_MarksReplacedReviewer(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	pr := model.PullRequest{PullRequestID: "pr1", Status: "OPEN", Assigned: []string{"u2", "u3"}, AuthorID: "u1"}
+	team := model.Team{TeamName: "backend", Members: []model.TeamMember{
+		{UserID: "u1", IsActive: true}, {UserID: "u2", IsActive: true}, {UserID: "u3", IsActive: true}, {UserID: "u4", IsActive: true},
+	}}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(model.User{UserID: "u2", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u2").Return([]string{"u1", "u3", "u4"}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "").Return([]string{"u1", "u2", "u3", "u4"}, nil)
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(team, nil)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", userIs("u4"), false), int64(0)).Return(swapReviewer(pr), nil)
+
+	_, replacedBy, ex, err := service.ReassignReviewerExplained(context.Background(), "pr1", "u2", "", 0, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u4", replacedBy)
+	if assert.NotNil(t, ex) && assert.Len(t, ex.Pools, 1) {
+		assert.Equal(t, []Exclusion{
+			{UserID: "u1", Reason: ExcludedAuthor},
+			{UserID: "u2", Reason: ExcludedReplaced},
+			{UserID: "u3", Reason: ExcludedAlreadyAssigned},
+		}, ex.Pools[0].Excluded)
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+)
+
+// PR lifecycle actions, each allowed only from the statuses listed in prTransitions.
+const (
+	ActionReady    = "ready"
+	ActionClose    = "close"
+	ActionReopen   = "reopen"
+	ActionMerge    = "merge"
+	ActionReassign = "reassign"
+	ActionReview   = "review"
+
+	ActionAddReviewer    = "add reviewer to"
+	ActionRemoveReviewer = "remove reviewer from"
+)
+
+// prTransitions is the PR state machine: the statuses an action is allowed from and the status it
+// leads to. Actions with an empty target keep the status.
+var prTransitions = map[string]struct {
+	from []string
+	to   string
+}{
+	ActionReady:    {from: []string{model.StatusDraft}, to: model.StatusOpen},
+	ActionClose:    {from: []string{model.StatusDraft, model.StatusOpen}, to: model.StatusClosed},
+	ActionReopen:   {from: []string{model.StatusClosed}, to: model.StatusOpen},
+	ActionMerge:    {from: []string{model.StatusOpen}, to: model.StatusMerged},
+	ActionReassign: {from: []string{model.StatusOpen}},
+	ActionReview:   {from: []string{model.StatusOpen}},
+
+	ActionAddReviewer:    {from: []string{model.StatusOpen}},
+	ActionRemoveReviewer: {from: []string{model.StatusOpen}},
+}
+
+// checkTransition rejects an action that is illegal for a PR in the given status with an explicit error code.
+func checkTransition(action, status string) error {
+	for _, from := range prTransitions[action].from {
+		if from == status {
+			return nil
+		}
+	}
+	switch {
+	case status == model.StatusMerged:
+		return apiErrors.APIError{Code: apiErrors.PRAlreadyMerged, Message: "cannot " + action + " merged PR"}
+	case action == ActionReady:
+		return apiErrors.APIError{Code: apiErrors.PRNotDraft, Message: "only draft PRs can be marked ready"}
+	case action == ActionReopen:
+		return apiErrors.APIError{Code: apiErrors.PRNotClosed, Message: "only closed PRs can be reopened"}
+	case status == model.StatusClosed:
+		return apiErrors.APIError{Code: apiErrors.PRClosed, Message: "cannot " + action + " closed PR"}
+	case status == model.StatusDraft:
+		return apiErrors.APIError{Code: apiErrors.PRDraft, Message: "cannot " + action + " draft PR, mark it ready first"}
+	default:
+		return apiErrors.APIError{Code: apiErrors.InternalError, Message: "unexpected PR status " + status}
+	}
+}
+
+// MarkReady moves a DRAFT PR to OPEN and assigns its reviewers.
+func (s *Service) MarkReady(ctx context.Context, prID string, ifVersion int64) (model.PullRequest, error) {
+	return s.transition(ctx, prID, ActionReady, ifVersion)
+}
+
+// ClosePR closes a DRAFT or OPEN PR without merging and releases its reviewers.
+func (s *Service) ClosePR(ctx context.Context, prID string, ifVersion int64) (model.PullRequest, error) {
+	return s.transition(ctx, prID, ActionClose, ifVersion)
+}
+
+// ReopenPR moves a CLOSED PR back to OPEN with freshly assigned reviewers.
+func (s *Service) ReopenPR(ctx context.Context, prID string, ifVersion int64) (model.PullRequest, error) {
+	return s.transition(ctx, prID, ActionReopen, ifVersion)
+}
+
+func (s *Service) transition(ctx context.Context, prID, action string, ifVersion int64) (model.PullRequest, error) {
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "PR not found"}
+		}
+		return model.PullRequest{}, err
+	}
+	if err := checkVersion(pr, ifVersion); err != nil {
+		return model.PullRequest{}, err
+	}
+	if err := checkTransition(action, pr.Status); err != nil {
+		return model.PullRequest{}, err
+	}
+
+	t := model.PRTransition{
+		PullRequestID:    prID,
+		From:             []string{pr.Status},
+		To:               prTransitions[action].to,
+		Version:          pr.Version,
+		ReleaseReviewers: true,
+	}
+	if t.To == model.StatusOpen {
+		author, err := s.getUser(ctx, pr.AuthorID)
+		if err != nil {
+			return model.PullRequest{}, err
+		}
+		if _, err := s.assignReviewers(ctx, &pr, author, assignOptions{}); err != nil {
+			return model.PullRequest{}, err
+		}
+		t.Assigned, t.Fallback, t.Assignment = pr.Assigned, pr.Fallback, pr.Assignment
+	}
+
+	updated, err := s.repo.TransitionPR(ctx, t)
+	if err != nil {
+		if errors.Is(err, model.ErrConflict) {
+			if ifVersion != 0 {
+				return model.PullRequest{}, preconditionFailed(ifVersion)
+			}
+			return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.Conflict, Message: "PR was modified concurrently, retry the request"}
+		}
+		return model.PullRequest{}, err
+	}
+	updated.UncoveredSkills, updated.MissingSeniors = pr.UncoveredSkills, pr.MissingSeniors
+	return updated, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCheckTransition(t *testing.T) {
+	cases := []struct {
+		action, status string
+		code           apiErrors.ErrorCode
+	}{
+		{ActionReady, model.StatusDraft, ""},
+		{ActionReady, model.StatusOpen, apiErrors.PRNotDraft},
+		{ActionClose, model.StatusDraft, ""},
+		{ActionClose, model.StatusOpen, ""},
+		{ActionClose, model.StatusClosed, apiErrors.PRClosed},
+		{ActionClose, model.StatusMerged, apiErrors.PRAlreadyMerged},
+		{ActionReopen, model.StatusClosed, ""},
+		{ActionReopen, model.StatusOpen, apiErrors.PRNotClosed},
+		{ActionReopen, model.StatusMerged, apiErrors.PRAlreadyMerged},
+		{ActionMerge, model.StatusDraft, apiErrors.PRDraft},
+		{ActionMerge, model.StatusClosed, apiErrors.PRClosed},
+		{ActionReassign, model.StatusDraft, apiErrors.PRDraft},
+		{ActionReview, model.StatusOpen, ""},
+	}
+	for _, c := range cases {
+		err := checkTransition(c.action, c.status)
+		if c.code == "" {
+			assert.NoError(t, err, "%s from %s", c.action, c.status)
+			continue
+		}
+		var apiErr apiErrors.APIError
+		if assert.ErrorAs(t, err, &apiErr, "%s from %s", c.action, c.status) {
+			assert.Equal(t, c.code, apiErr.Code, "%s from %s", c.action, c.status)
+		}
+	}
+}
+
+func TestCreateDraftPR_NoReviewers(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
+		return pr.Status == model.StatusDraft && len(pr.Assigned) == 0
+	})).Return(nil)
+
+	result, err := service.CreateDraftPR(context.Background(), "pr1", "WIP", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusDraft, result.Status)
+	mockRepo.AssertNotCalled(t, "GetActiveTeamMembersExcept", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMarkReady_AssignsReviewers(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	draft := model.PullRequest{PullRequestID: "pr1", AuthorID: "u1", Status: model.StatusDraft, Version: 1}
+	opened := model.PullRequest{PullRequestID: "pr1", AuthorID: "u1", Status: model.StatusOpen, Assigned: []string{"u2"}, Version: 2}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(draft, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2"}, nil)
+	mockRepo.On("TransitionPR", mock.Anything, model.PRTransition{
+		PullRequestID:    "pr1",
+		From:             []string{model.StatusDraft},
+		To:               model.StatusOpen,
+		Version:          1,
+		ReleaseReviewers: true,
+		Assigned:         []string{"u2"},
+		Assignment:       model.AssignmentInfo{Strategy: StrategyRandom},
+	}).Return(opened, nil)
+
+	result, err := service.MarkReady(context.Background(), "pr1", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, opened, result)
+}
+
+func TestClosePR_ReleasesReviewers(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	open := model.PullRequest{PullRequestID: "pr1", AuthorID: "u1", Status: model.StatusOpen, Assigned: []string{"u2"}, Version: 3}
+	closed := model.PullRequest{PullRequestID: "pr1", AuthorID: "u1", Status: model.StatusClosed, Version: 4}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(open, nil)
+	mockRepo.On("TransitionPR", mock.Anything, model.PRTransition{
+		PullRequestID:    "pr1",
+		From:             []string{model.StatusOpen},
+		To:               model.StatusClosed,
+		Version:          3,
+		ReleaseReviewers: true,
+	}).Return(closed, nil)
+
+	result, err := service.ClosePR(context.Background(), "pr1", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusClosed, result.Status)
+}
+
+func TestReopenPR_RejectsOpen(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{PullRequestID: "pr1", Status: model.StatusOpen}, nil)
+
+	_, err := service.ReopenPR(context.Background(), "pr1", 0)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.PRNotClosed, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "TransitionPR", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// OwnerMatch is the code owner rule that owns a changed path.
+type OwnerMatch struct {
+	Path     string `json:"path"`
+	Pattern  string `json:"pattern"`
+	TeamName string `json:"team_name,omitempty"`
+	UserID   string `json:"user_id,omitempty"`
+}
+
+func (s *Service) GetCodeOwners(ctx context.Context) ([]model.CodeOwnerRule, error) {
+	return s.repo.ListCodeOwnerRules(ctx)
+}
+
+// SetCodeOwners replaces the code owner rules. Every rule needs a valid pattern and exactly one
+// existing owner, a team or a user.
+func (s *Service) SetCodeOwners(ctx context.Context, rules []model.CodeOwnerRule) ([]model.CodeOwnerRule, error) {
+	for _, rule := range rules {
+		if _, err := compilePattern(rule.Pattern); err != nil {
+			return nil, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "invalid pattern " + rule.Pattern}
+		}
+		switch {
+		case (rule.TeamName == "") == (rule.UserID == ""):
+			return nil, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "rule " + rule.Pattern + " needs exactly one of team_name and user_id"}
+		case rule.TeamName != "":
+			if _, err := s.GetTeam(ctx, rule.TeamName); err != nil {
+				return nil, err
+			}
+		default:
+			if _, err := s.getUser(ctx, rule.UserID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if rules == nil {
+		rules = []model.CodeOwnerRule{}
+	}
+	if err := s.repo.ReplaceCodeOwnerRules(ctx, rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// normalizePaths cleans changed file paths to the slash separated, repository relative form rules match against.
+func normalizePaths(paths []string) ([]string, error) {
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		clean := strings.TrimPrefix(path.Clean("/"+strings.TrimSpace(p)), "/")
+		if clean == "" {
+			return nil, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "changed_files must not contain empty paths"}
+		}
+		if !contains(out, clean) {
+			out = append(out, clean)
+		}
+	}
+	return out, nil
+}
+
+// resolveOwners returns the rule owning each of paths, paths no rule matches are left out.
+// Like in CODEOWNERS, the last matching rule wins.
+func resolveOwners(rules []model.CodeOwnerRule, paths []string) ([]OwnerMatch, error) {
+	patterns := make([]*regexp.Regexp, len(rules))
+	for i, rule := range rules {
+		re, err := compilePattern(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		patterns[i] = re
+	}
+
+	var out []OwnerMatch
+	for _, p := range paths {
+		for i := len(rules) - 1; i >= 0; i-- {
+			if patterns[i].MatchString(p) {
+				out = append(out, OwnerMatch{Path: p, Pattern: rules[i].Pattern, TeamName: rules[i].TeamName, UserID: rules[i].UserID})
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// compilePattern turns a CODEOWNERS glob into a regexp matching repository relative paths.
+// "*" and "?" never cross a "/", "**" does. A pattern without a slash matches at any depth,
+// and a pattern matching a directory also matches everything below it.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	p := strings.TrimSpace(pattern)
+	anchored := strings.Contains(strings.TrimSuffix(p, "/"), "/")
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil, errors.New("empty pattern")
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	runes := []rune(p)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case c == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			i++
+			if i+1 < len(runes) && runes[i+1] == '/' {
+				i++
+				b.WriteString("(?:.*/)?")
+			} else {
+				b.WriteString(".*")
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if dirOnly {
+		b.WriteString("/.*$")
+	} else {
+		b.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(b.String())
+}
+
+// pickOwners picks the reviewers the changed files of base.PR require: every owning user and one
+// member of every owning team, unless a reviewer of that team was already picked. Owners who are
+// the author, unavailable or at capacity are skipped. Matches and owner pools are recorded in ex if it is set.
+func (s *Service) pickOwners(ctx context.Context, strategy AssignmentStrategy, base AssignmentRequest, ex *Explanation) ([]string, error) {
+	pr := base.PR
+	if len(pr.ChangedFiles) == 0 {
+		return nil, nil
+	}
+	rules, err := s.repo.ListCodeOwnerRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := resolveOwners(rules, pr.ChangedFiles)
+	if err != nil {
+		return nil, err
+	}
+	if ex != nil {
+		ex.Owners = matches
+	}
+
+	var picked []string
+	teams := make(map[string]bool)
+	for _, m := range matches {
+		if m.UserID != "" {
+			if m.UserID == pr.AuthorID || contains(picked, m.UserID) {
+				continue
+			}
+			u, err := s.repo.GetUser(ctx, m.UserID)
+			if err != nil {
+				return nil, err
+			}
+			available, err := s.repo.GetActiveTeamMembersExcept(ctx, u.TeamName, pr.AuthorID)
+			if err != nil {
+				return nil, err
+			}
+			free, _, err := s.withoutFull(ctx, []string{m.UserID}, nil)
+			if err != nil {
+				return nil, err
+			}
+			if contains(available, m.UserID) && len(free) > 0 {
+				picked = append(picked, m.UserID)
+				if ex != nil {
+					ex.Picked = append(ex.Picked, m.UserID)
+				}
+			}
+			continue
+		}
+
+		if teams[m.TeamName] {
+			continue
+		}
+		teams[m.TeamName] = true
+		candidates, err := s.repo.GetActiveTeamMembersExcept(ctx, m.TeamName, pr.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		if len(without(candidates, picked)) < len(candidates) {
+			continue // the team already has a reviewer
+		}
+		excluded := exclusions(pr, picked)
+		if candidates, _, err = s.withoutFull(ctx, candidates, excluded); err != nil {
+			return nil, err
+		}
+		req := base.stage("owners", len(teams))
+		req.Candidates, req.Count = candidates, 1
+		got, err := s.pick(ctx, strategy, req, ex, m.TeamName, false, excluded)
+		if err != nil {
+			return nil, err
+		}
+		if ex != nil {
+			ex.Pools[len(ex.Pools)-1].Owner = true
+		}
+		picked = append(picked, got...)
+	}
+	return picked, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCompilePattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"*.sql", "db/migrations/0001.sql", true},
+		{"*.sql", "db/query.go", false},
+		{"docs/", "docs/api/readme.md", true},
+		{"docs/", "src/docs/readme.md", true},
+		{"/docs/", "src/docs/readme.md", false},
+		{"src/api/*.go", "src/api/handler.go", true},
+		{"src/api/*.go", "src/api/v2/handler.go", false},
+		{"src/**/handler.go", "src/api/v2/handler.go", true},
+		{"src/**/handler.go", "src/handler.go", true},
+		{"/src/internal", "src/internal/store/repo.go", true},
+		{"/src/internal", "src/internalx/repo.go", false},
+		{"Makefile", "build/Makefile", true},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file12.txt", false},
+		{"*", "any/path.go", true},
+	}
+	for _, c := range cases {
+		re, err := compilePattern(c.pattern)
+		if assert.NoError(t, err, c.pattern) {
+			assert.Equal(t, c.match, re.MatchString(c.path), "%s ~ %s", c.pattern, c.path)
+		}
+	}
+
+	_, err := compilePattern("/")
+	assert.Error(t, err)
+}
+
+func TestResolveOwners_LastMatchWins(t *testing.T) {
+	rules := []model.CodeOwnerRule{
+		{Pattern: "*", TeamName: "backend"},
+		{Pattern: "docs/", TeamName: "docs"},
+		{Pattern: "docs/security.md", UserID: "u9"},
+	}
+
+	matches, err := resolveOwners(rules, []string{"main.go", "docs/intro.md", "docs/security.md"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []OwnerMatch{
+		{Path: "main.go", Pattern: "*", TeamName: "backend"},
+		{Path: "docs/intro.md", Pattern: "docs/", TeamName: "docs"},
+		{Path: "docs/security.md", Pattern: "docs/security.md", UserID: "u9"},
+	}, matches)
+}
+
+func TestCreatePR_CodeOwnersFirst(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u9").Return(model.User{UserID: "u9", TeamName: "dba", IsActive: true}, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 3}, nil)
+	mockRepo.On("ListCodeOwnerRules", mock.Anything).Return([]model.CodeOwnerRule{
+		{Pattern: "docs/", TeamName: "docs"},
+		{Pattern: "*.sql", UserID: "u9"},
+	}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "docs", "u1").Return([]string{"d1"}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "dba", "u1").Return([]string{"u9"}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3"}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
+		return assert.ObjectsAreEqual([]string{"docs/intro.md", "db/0001.sql", "main.go"}, pr.ChangedFiles)
+	})).Return(nil)
+
+	pr, _, err := service.CreatePRExplained(context.Background(), CreatePRRequest{
+		PullRequestID:   "pr1",
+		PullRequestName: "Owned PR",
+		AuthorID:        "u1",
+		ChangedFiles:    []string{"./docs/intro.md", "/db/0001.sql", "main.go", "docs/intro.md"},
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, pr.Assigned, 3) {
+		assert.Equal(t, []string{"d1", "u9"}, pr.Assigned[:2])
+		assert.Contains(t, []string{"u2", "u3"}, pr.Assigned[2])
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreatePR_CodeOwnerTeamAlreadyCovered(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(model.User{UserID: "u2", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 1}, nil)
+	mockRepo.On("ListCodeOwnerRules", mock.Anything).Return([]model.CodeOwnerRule{
+		{Pattern: "api/", UserID: "u2"},
+		{Pattern: "*.go", TeamName: "backend"},
+	}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3"}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	pr, _, err := service.CreatePRExplained(context.Background(), CreatePRRequest{
+		PullRequestID:   "pr1",
+		PullRequestName: "Owned PR",
+		AuthorID:        "u1",
+		ChangedFiles:    []string{"api/spec.yml", "main.go"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2"}, pr.Assigned, "u2 owns api/ and already covers the backend team")
+}
+
+func TestSetCodeOwners_Validation(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetTeam", mock.Anything, "ghost").Return(model.Team{}, model.ErrNotFound)
+
+	cases := []struct {
+		rule model.CodeOwnerRule
+		code apiErrors.ErrorCode
+	}{
+		{model.CodeOwnerRule{Pattern: "/", TeamName: "backend"}, apiErrors.InvalidArgument},
+		{model.CodeOwnerRule{Pattern: "docs/"}, apiErrors.InvalidArgument},
+		{model.CodeOwnerRule{Pattern: "docs/", TeamName: "docs", UserID: "u1"}, apiErrors.InvalidArgument},
+		{model.CodeOwnerRule{Pattern: "docs/", TeamName: "ghost"}, apiErrors.NotFound},
+	}
+	for _, c := range cases {
+		_, err := service.SetCodeOwners(context.Background(), []model.CodeOwnerRule{c.rule})
+
+		var apiErr apiErrors.APIError
+		if assert.ErrorAs(t, err, &apiErr, c.rule.Pattern) {
+			assert.Equal(t, c.code, apiErr.Code)
+		}
+	}
+	mockRepo.AssertNotCalled(t, "ReplaceCodeOwnerRules", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+)
+
+// AddReviewer assigns a specific user as an extra reviewer of an OPEN PR.
+// The user must be an active member of the author's team or one of its fallback teams.
+func (s *Service) AddReviewer(ctx context.Context, prID, userID string, ifVersion int64) (model.PullRequest, error) {
+	pr, err := s.openPRForReviewerChange(ctx, prID, ActionAddReviewer, ifVersion)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	author, err := s.getUser(ctx, pr.AuthorID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	settings, err := s.teamSettings(ctx, author.TeamName)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	isFallback, err := s.validateReviewer(ctx, pr, author.TeamName, settings.FallbackTeams, userID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+
+	updated, err := s.repo.AssignReviewer(ctx, model.ReviewerChange{
+		PullRequestID: prID,
+		UserID:        userID,
+		IsFallback:    isFallback,
+		Version:       pr.Version,
+	})
+	if err != nil {
+		if ifVersion != 0 && errors.Is(err, model.ErrConflict) {
+			return model.PullRequest{}, preconditionFailed(ifVersion)
+		}
+		return model.PullRequest{}, reviewerChangeError(err)
+	}
+	return updated, nil
+}
+
+// RemoveReviewer unassigns a reviewer from an OPEN PR without a replacement,
+// as long as the PR keeps the minimum number of reviewers its team requires.
+// Removing a senior the PR needs follows the team's senior policy: it fails, or the shortfall is reported.
+func (s *Service) RemoveReviewer(ctx context.Context, prID, userID string, ifVersion int64) (model.PullRequest, error) {
+	pr, err := s.openPRForReviewerChange(ctx, prID, ActionRemoveReviewer, ifVersion)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	if !contains(pr.Assigned, userID) {
+		return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.NotAssigned, Message: "reviewer is not assigned to this PR"}
+	}
+	author, err := s.getUser(ctx, pr.AuthorID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	settings, err := s.teamSettings(ctx, author.TeamName)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	if len(pr.Assigned)-1 < settings.MinReviewers {
+		return model.PullRequest{}, apiErrors.APIError{
+			Code:    apiErrors.NotEnoughReviewers,
+			Message: fmt.Sprintf("team requires at least %d reviewers, PR has %d", settings.MinReviewers, len(pr.Assigned)),
+		}
+	}
+	missingSeniors, err := s.seniorsAfterChange(ctx, settings, pr.Assigned, without(pr.Assigned, []string{userID}))
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+
+	updated, err := s.repo.UnassignReviewer(ctx, model.ReviewerChange{
+		PullRequestID: prID,
+		UserID:        userID,
+		MinReviewers:  settings.MinReviewers,
+		Version:       pr.Version,
+	})
+	if err != nil {
+		if ifVersion != 0 && errors.Is(err, model.ErrConflict) {
+			return model.PullRequest{}, preconditionFailed(ifVersion)
+		}
+		return model.PullRequest{}, reviewerChangeError(err)
+	}
+	updated.MissingSeniors = missingSeniors
+	return updated, nil
+}
+
+func (s *Service) openPRForReviewerChange(ctx context.Context, prID, action string, ifVersion int64) (model.PullRequest, error) {
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "PR not found"}
+		}
+		return model.PullRequest{}, err
+	}
+	if err := checkVersion(pr, ifVersion); err != nil {
+		return model.PullRequest{}, err
+	}
+	if err := checkTransition(action, pr.Status); err != nil {
+		return model.PullRequest{}, err
+	}
+	return pr, nil
+}
+
+// validateReviewer checks that userID may review pr: not the author, not assigned yet,
+// a member of team or one of fallbackTeams, active, not absent and below their open review limit. It reports whether
+// the user comes from a fallback team and returns an error naming the failed rule.
+func (s *Service) validateReviewer(ctx context.Context, pr model.PullRequest, team string, fallbackTeams []string, userID string) (bool, error) {
+	if userID == pr.AuthorID {
+		return false, apiErrors.APIError{Code: apiErrors.ReviewerIsAuthor, Message: "the PR author cannot review their own PR"}
+	}
+	if contains(pr.Assigned, userID) {
+		return false, apiErrors.APIError{Code: apiErrors.AlreadyAssigned, Message: "user is already assigned to this PR"}
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	isFallback := false
+	if user.TeamName != team {
+		if !contains(fallbackTeams, user.TeamName) {
+			return false, apiErrors.APIError{
+				Code:    apiErrors.ReviewerNotInTeam,
+				Message: fmt.Sprintf("user belongs to team %q, not %q or its fallback teams", user.TeamName, team),
+			}
+		}
+		isFallback = true
+	}
+	if !user.IsActive {
+		return false, apiErrors.APIError{Code: apiErrors.ReviewerInactive, Message: "user is inactive"}
+	}
+	available, err := s.repo.GetActiveTeamMembersExcept(ctx, user.TeamName, pr.AuthorID)
+	if err != nil {
+		return false, err
+	}
+	if !contains(available, userID) {
+		return false, apiErrors.APIError{Code: apiErrors.ReviewerAbsent, Message: "user is absent"}
+	}
+	if err := s.checkCapacity(ctx, userID); err != nil {
+		return false, err
+	}
+	return isFallback, nil
+}
+
+func contains(items []string, v string) bool {
+	for _, it := range items {
+		if it == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func openPR() model.PullRequest {
+	return model.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "u1",
+		Status:        model.StatusOpen,
+		Assigned:      []string{"u2", "u3"},
+		Version:       2,
+	}
+}
+
+func TestAddReviewer_Success(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	updated := openPR()
+	updated.Assigned = append(updated.Assigned, "u4")
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR(), nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u4").Return(model.User{UserID: "u4", TeamName: "platform", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 2, FallbackTeams: []string{"platform"}}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "platform", "u1").Return([]string{"u4", "u5"}, nil)
+	mockRepo.On("AssignReviewer", mock.Anything, model.ReviewerChange{PullRequestID: "pr1", UserID: "u4", IsFallback: true, Version: 2}).Return(updated, nil)
+
+	result, err := service.AddReviewer(context.Background(), "pr1", "u4", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u3", "u4"}, result.Assigned)
+}
+
+func TestAddReviewer_ValidationRules(t *testing.T) {
+	cases := []struct {
+		name   string
+		userID string
+		user   model.User
+		active []string
+		code   apiErrors.ErrorCode
+	}{
+		{name: "author", userID: "u1", code: apiErrors.ReviewerIsAuthor},
+		{name: "already assigned", userID: "u2", code: apiErrors.AlreadyAssigned},
+		{name: "other team", userID: "u9", user: model.User{UserID: "u9", TeamName: "mobile", IsActive: true}, code: apiErrors.ReviewerNotInTeam},
+		{name: "inactive", userID: "u5", user: model.User{UserID: "u5", TeamName: "backend"}, code: apiErrors.ReviewerInactive},
+		{name: "absent", userID: "u6", user: model.User{UserID: "u6", TeamName: "backend", IsActive: true}, active: []string{"u2", "u3"}, code: apiErrors.ReviewerAbsent},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service, mockRepo := createTestService()
+
+			mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR(), nil)
+			mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+			mockRepo.On("GetUser", mock.Anything, c.userID).Return(c.user, nil)
+			mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+			mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return(c.active, nil)
+
+			_, err := service.AddReviewer(context.Background(), "pr1", c.userID, 0)
+
+			var apiErr apiErrors.APIError
+			if assert.ErrorAs(t, err, &apiErr) {
+				assert.Equal(t, c.code, apiErr.Code)
+			}
+			mockRepo.AssertNotCalled(t, "AssignReviewer", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRemoveReviewer_RespectsMinReviewers(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR(), nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 2, MinReviewers: 2}, nil)
+
+	_, err := service.RemoveReviewer(context.Background(), "pr1", "u2", 0)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotEnoughReviewers, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "UnassignReviewer", mock.Anything, mock.Anything)
+}
+
+func TestRemoveReviewer_Success(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	updated := openPR()
+	updated.Assigned = []string{"u3"}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR(), nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 2, MinReviewers: 1}, nil)
+	mockRepo.On("UnassignReviewer", mock.Anything, model.ReviewerChange{PullRequestID: "pr1", UserID: "u2", MinReviewers: 1, Version: 2}).Return(updated, nil)
+
+	result, err := service.RemoveReviewer(context.Background(), "pr1", "u2", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3"}, result.Assigned)
+}
+
+func TestRemoveReviewer_MergedPR(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	merged := openPR()
+	merged.Status = model.StatusMerged
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(merged, nil)
+
+	_, err := service.RemoveReviewer(context.Background(), "pr1", "u2", 0)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.PRAlreadyMerged, apiErr.Code)
+}
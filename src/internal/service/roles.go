@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/store"
+)
+
+// What happens when a PR cannot get the senior reviewers its team requires.
+const (
+	SeniorPolicyFail = "fail" // reject with NOT_ENOUGH_SENIORS, the default
+	SeniorPolicyWarn = "warn" // assign anyway and report missing_seniors on the PR
+)
+
+// normalizeRole defaults an empty role to member and rejects unknown ones.
+func normalizeRole(role string) (string, error) {
+	switch role {
+	case "":
+		return model.RoleMember, nil
+	case model.RoleMember, model.RoleSenior, model.RoleLead:
+		return role, nil
+	default:
+		return "", apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "role must be one of member, senior, lead"}
+	}
+}
+
+// SetMemberRole changes the role of a member of teamName.
+func (s *Service) SetMemberRole(ctx context.Context, teamName, userID, role string) (model.User, error) {
+	if role == "" {
+		return model.User{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "role required"}
+	}
+	role, err := normalizeRole(role)
+	if err != nil {
+		return model.User{}, err
+	}
+	u, err := s.repo.SetUserRole(ctx, teamName, userID, role)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return model.User{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "user " + userID + " is not a member of team " + teamName}
+		}
+		return model.User{}, err
+	}
+	return u, nil
+}
+
+// seniorsMissing returns how many of want senior reviewers reviewers lack.
+func (s *Service) seniorsMissing(ctx context.Context, want int, reviewers []string) (int, error) {
+	if want <= 0 {
+		return 0, nil
+	}
+	if len(reviewers) == 0 {
+		return want, nil
+	}
+	roles, err := s.repo.GetUserRoles(ctx, reviewers)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range reviewers {
+		if model.IsSenior(roles[id]) {
+			want--
+		}
+	}
+	return max(want, 0), nil
+}
+
+// enforceSeniors applies the senior policy of settings to reviewers lacking missing seniors.
+// It returns the shortfall to report when the policy only warns.
+func enforceSeniors(settings model.TeamSettings, missing int) (int, error) {
+	if missing <= 0 {
+		return 0, nil
+	}
+	if settings.SeniorPolicy == SeniorPolicyWarn {
+		return missing, nil
+	}
+	return 0, apiErrors.APIError{
+		Code: apiErrors.NotEnoughSeniors,
+		Message: fmt.Sprintf("team requires at least %d senior reviewers, %d available",
+			settings.MinSeniorReviewers, settings.MinSeniorReviewers-missing),
+	}
+}
+
+// seniorsAfterChange applies the senior policy of settings to a reviewer change turning the reviewers before
+// into after. Only a change losing a senior the PR needs violates the policy, a PR short of seniors already
+// can still be reassigned. It returns how many seniors after lacks.
+func (s *Service) seniorsAfterChange(ctx context.Context, settings model.TeamSettings, before, after []string) (int, error) {
+	want := settings.MinSeniorReviewers
+	if want <= 0 {
+		return 0, nil
+	}
+	roles, err := s.repo.GetUserRoles(ctx, append(append([]string(nil), before...), after...))
+	if err != nil {
+		return 0, err
+	}
+	missing := func(reviewers []string) int {
+		n := want
+		for _, id := range reviewers {
+			if model.IsSenior(roles[id]) {
+				n--
+			}
+		}
+		return max(n, 0)
+	}
+	missingAfter := missing(after)
+	if missingAfter > missing(before) {
+		if _, err := enforceSeniors(settings, missingAfter); err != nil {
+			return 0, err
+		}
+	}
+	return missingAfter, nil
+}
+
+// withSeniorPolicyOf returns settings, the settings of team, with the senior policy of authorTeam.
+// Whoever reviews a PR, its author's team decides how many seniors it needs.
+func (s *Service) withSeniorPolicyOf(ctx context.Context, authorTeam, team string, settings model.TeamSettings) (model.TeamSettings, error) {
+	if authorTeam == team {
+		return settings, nil
+	}
+	policy, err := s.teamSettings(ctx, authorTeam)
+	if err != nil {
+		return model.TeamSettings{}, err
+	}
+	settings.MinSeniorReviewers, settings.SeniorPolicy = policy.MinSeniorReviewers, policy.SeniorPolicy
+	return settings, nil
+}
+
+// SeniorityStrategy fills the first req.MinSeniors slots with senior or lead candidates and the
+// rest with anyone, letting the wrapped strategy choose within both groups. Without a senior
+// requirement it is the wrapped strategy.
+type SeniorityStrategy struct {
+	inner AssignmentStrategy
+	repo  store.Repository
+}
+
+func NewSeniorityStrategy(inner AssignmentStrategy, repo store.Repository) *SeniorityStrategy {
+	return &SeniorityStrategy{inner: inner, repo: repo}
+}
+
+func (s *SeniorityStrategy) Name() string { return s.inner.Name() }
+
+func (s *SeniorityStrategy) Pick(ctx context.Context, req AssignmentRequest) ([]string, error) {
+	if req.MinSeniors <= 0 || len(req.Candidates) == 0 || req.Count <= 0 {
+		return s.inner.Pick(ctx, req)
+	}
+	roles, err := s.roles(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var seniors []string
+	for _, id := range req.Candidates {
+		if model.IsSenior(roles[id]) {
+			seniors = append(seniors, id)
+		}
+	}
+
+	var picked []string
+	if len(seniors) > 0 {
+		sub := req.stage("seniors", 0)
+		sub.Candidates, sub.Count = seniors, min(req.MinSeniors, req.Count)
+		if picked, err = s.inner.Pick(ctx, sub); err != nil {
+			return nil, err
+		}
+	}
+	if rest := req.Count - len(picked); rest > 0 {
+		sub := req.stage("seniors_rest", 0)
+		sub.Candidates, sub.Count = without(req.Candidates, picked), rest
+		got, err := s.inner.Pick(ctx, sub)
+		if err != nil {
+			return nil, err
+		}
+		picked = append(picked, got...)
+	}
+	return picked, nil
+}
+
+// Inputs reports the role of every candidate next to the inputs of the wrapped strategy.
+func (s *SeniorityStrategy) Inputs(ctx context.Context, req AssignmentRequest) (map[string]any, error) {
+	inputs := map[string]any{}
+	if r, ok := s.inner.(InputReporter); ok {
+		var err error
+		if inputs, err = r.Inputs(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	if req.MinSeniors <= 0 {
+		return inputs, nil
+	}
+	roles, err := s.roles(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	candidateRoles := make(map[string]string, len(req.Candidates))
+	for _, id := range req.Candidates {
+		candidateRoles[id] = roles[id]
+	}
+	inputs["roles"] = candidateRoles
+	inputs["min_seniors"] = req.MinSeniors
+	return inputs, nil
+}
+
+func (s *SeniorityStrategy) roles(ctx context.Context, req AssignmentRequest) (map[string]string, error) {
+	if req.Roles != nil {
+		return req.Roles, nil
+	}
+	return s.repo.GetUserRoles(ctx, req.Candidates)
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testRoles = map[string]string{
+	"u1": model.RoleMember, "u2": model.RoleMember, "u3": model.RoleSenior, "u4": model.RoleMember, "u5": model.RoleLead,
+}
+
+func TestSeniorityStrategy_PicksSeniorsFirst(t *testing.T) {
+	strategy := NewSeniorityStrategy(NewRoundRobinStrategy(), new(MockRepositories))
+
+	picked, err := strategy.Pick(context.Background(), AssignmentRequest{
+		Candidates: []string{"u2", "u3", "u4", "u5"},
+		Count:      3,
+		MinSeniors: 2,
+		Roles:      testRoles,
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, picked, 3) {
+		assert.ElementsMatch(t, []string{"u3", "u5"}, picked[:2])
+		assert.Contains(t, []string{"u2", "u4"}, picked[2])
+	}
+}
+
+func seniorPolicyTeam(mockRepo *MockRepositories, settings model.TeamSettings, members []string) {
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(settings, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return(members, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, mock.Anything).Return(testRoles, nil)
+}
+
+func TestCreatePR_AssignsRequiredSenior(t *testing.T) {
+	service, mockRepo := createTestService()
+	seniorPolicyTeam(mockRepo, model.TeamSettings{TeamName: "backend", ReviewersCount: 2, MinSeniorReviewers: 1}, []string{"u2", "u3", "u4"})
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(context.Background(), "pr1", "Reviewed by a senior", "u1")
+
+	assert.NoError(t, err)
+	assert.Len(t, pr.Assigned, 2)
+	assert.Contains(t, pr.Assigned, "u3")
+	assert.Zero(t, pr.MissingSeniors)
+}
+
+func TestCreatePR_NotEnoughSeniors(t *testing.T) {
+	service, mockRepo := createTestService()
+	seniorPolicyTeam(mockRepo, model.TeamSettings{TeamName: "backend", ReviewersCount: 2, MinSeniorReviewers: 1}, []string{"u2", "u4"})
+
+	_, err := service.CreatePR(context.Background(), "pr1", "No seniors around", "u1")
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotEnoughSeniors, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "CreatePRWithReviewers", mock.Anything, mock.Anything)
+}
+
+func TestCreatePR_SeniorPolicyWarn(t *testing.T) {
+	service, mockRepo := createTestService()
+	settings := model.TeamSettings{TeamName: "backend", ReviewersCount: 2, MinSeniorReviewers: 2, SeniorPolicy: SeniorPolicyWarn}
+	seniorPolicyTeam(mockRepo, settings, []string{"u2", "u3", "u4"})
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(context.Background(), "pr1", "One senior short", "u1")
+
+	assert.NoError(t, err)
+	assert.Contains(t, pr.Assigned, "u3")
+	assert.Equal(t, 1, pr.MissingSeniors)
+}
+
+func TestReassignReviewer_ReplacesSeniorWithSenior(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	pr := model.PullRequest{PullRequestID: "pr1", Status: model.StatusOpen, Assigned: []string{"u3", "u2"}, AuthorID: "u1"}
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u3").Return(model.User{UserID: "u3", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 2, MinSeniorReviewers: 1}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u3").Return([]string{"u1", "u2", "u4", "u5"}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, mock.Anything).Return(testRoles, nil)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u3", func(id string) bool { return id == "u5" }, false), int64(0)).
+		Return(swapReviewer(pr), nil)
+
+	_, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u3", "", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u5", newReviewer, "u5 is the only senior left to replace u3")
+}
+
+func TestReassignReviewer_ManualBreaksSeniorPolicy(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	pr := model.PullRequest{PullRequestID: "pr1", Status: model.StatusOpen, Assigned: []string{"u3", "u2"}, AuthorID: "u1"}
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u3").Return(model.User{UserID: "u3", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u4").Return(model.User{UserID: "u4", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 2, MinSeniorReviewers: 1}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3", "u4", "u5"}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, mock.Anything).Return(testRoles, nil)
+
+	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u3", "u4", 0)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotEnoughSeniors, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "ReplaceReviewer", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRemoveReviewer_SeniorPolicyFail(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR(), nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 2, MinReviewers: 1, MinSeniorReviewers: 1}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, mock.Anything).Return(testRoles, nil)
+
+	_, err := service.RemoveReviewer(context.Background(), "pr1", "u3", 0)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotEnoughSeniors, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "UnassignReviewer", mock.Anything, mock.Anything)
+}
+
+func TestRemoveReviewer_SeniorPolicyWarn(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	updated := openPR()
+	updated.Assigned = []string{"u2"}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR(), nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{
+		TeamName: "backend", ReviewersCount: 2, MinReviewers: 1, MinSeniorReviewers: 1, SeniorPolicy: SeniorPolicyWarn,
+	}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, mock.Anything).Return(testRoles, nil)
+	mockRepo.On("UnassignReviewer", mock.Anything, model.ReviewerChange{PullRequestID: "pr1", UserID: "u3", MinReviewers: 1, Version: 2}).Return(updated, nil)
+
+	result, err := service.RemoveReviewer(context.Background(), "pr1", "u3", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2"}, result.Assigned)
+	assert.Equal(t, 1, result.MissingSeniors)
+}
+
+func TestReassignReviewer_AuthorTeamSeniorPolicy(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	// u6 is a senior of platform reviewing a PR of backend, which requires a senior
+	pr := model.PullRequest{PullRequestID: "pr1", Status: model.StatusOpen, Assigned: []string{"u6", "u2"}, AuthorID: "u1"}
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u6").Return(model.User{UserID: "u6", TeamName: "platform", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u8").Return(model.User{UserID: "u8", TeamName: "platform", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").
+		Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 2, MinSeniorReviewers: 1, FallbackTeams: []string{"platform"}}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "platform", "u1").Return([]string{"u6", "u8"}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, mock.Anything).Return(map[string]string{"u6": model.RoleSenior}, nil)
+
+	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u6", "u8", 0)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotEnoughSeniors, apiErr.Code, "the policy of the author's team applies")
+	mockRepo.AssertNotCalled(t, "ReplaceReviewer", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReassignReviewer_AlreadyShortOfSeniors(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	pr := model.PullRequest{PullRequestID: "pr1", Status: model.StatusOpen, Assigned: []string{"u2", "u4"}, AuthorID: "u1"}
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(model.User{UserID: "u2", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 2, MinSeniorReviewers: 1}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u2").Return([]string{"u1", "u4", "u6"}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, mock.Anything).Return(testRoles, nil)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", userIs("u6"), false), int64(0)).Return(swapReviewer(pr), nil)
+
+	updated, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
+
+	assert.NoError(t, err, "a swap that loses no senior should not be blocked")
+	assert.Equal(t, "u6", newReviewer)
+	assert.Equal(t, 1, updated.MissingSeniors)
+}
+
+func TestDeactivateTeamUsers_SeniorPolicy(t *testing.T) {
+	cases := []struct {
+		name         string
+		policy       string
+		u4Role       string
+		wantReviewer string
+		wantMissing  int
+		wantFailed   apiErrors.ErrorCode
+	}{
+		{name: "senior left", policy: SeniorPolicyFail, u4Role: model.RoleSenior, wantReviewer: "u4"},
+		{name: "fail", policy: SeniorPolicyFail, u4Role: model.RoleMember, wantFailed: apiErrors.NotEnoughSeniors},
+		{name: "warn", policy: SeniorPolicyWarn, u4Role: model.RoleMember, wantMissing: 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service, mockRepo := createTestService()
+
+			// u9 of platform, which requires a senior, loses its senior reviewer u3
+			team := model.Team{TeamName: "backend", Members: []model.TeamMember{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}}}
+			pr := model.PullRequest{PullRequestID: "pr1", AuthorID: "u9", Status: model.StatusOpen, Assigned: []string{"u3"}}
+			mockRepo.On("GetTeam", mock.Anything, "backend").Return(team, nil)
+			mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+			mockRepo.On("GetTeamSettings", mock.Anything, "platform").Return(model.TeamSettings{TeamName: "platform", ReviewersCount: 2, MinSeniorReviewers: 1, SeniorPolicy: c.policy}, nil)
+			mockRepo.On("GetUser", mock.Anything, "u9").Return(model.User{UserID: "u9", TeamName: "platform", IsActive: true}, nil)
+			mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "").Return([]string{"u1", "u2", "u3", "u4"}, nil)
+			mockRepo.On("GetOpenReviewCounts", mock.Anything, []string{"u1", "u4"}).Return(map[string]int{"u1": 1}, nil)
+			mockRepo.On("GetOpenPRsForReviewers", mock.Anything, []string{"u2", "u3"}).Return([]model.PullRequest{pr}, nil)
+			mockRepo.On("GetUserRoles", mock.Anything, mock.Anything).Return(map[string]string{"u3": model.RoleSenior, "u4": c.u4Role}, nil)
+			var swaps []model.ReviewerSwap
+			mockRepo.On("DeactivateUsersAndReassign", mock.Anything, "backend", []string{"u2", "u3"}, mock.Anything).
+				Run(func(args mock.Arguments) { swaps = args.Get(3).([]model.ReviewerSwap) }).Return(nil)
+
+			result, err := service.DeactivateTeamUsers(context.Background(), "backend", []string{"u2", "u3"})
+
+			assert.NoError(t, err)
+			if c.wantFailed != "" {
+				assert.Empty(t, result.Reassigned)
+				assert.Empty(t, swaps)
+				if assert.Len(t, result.Failed, 1) {
+					assert.Equal(t, c.wantFailed, result.Failed[0].Code)
+				}
+				return
+			}
+			assert.Empty(t, result.Failed)
+			if assert.Len(t, result.Reassigned, 1) {
+				assert.Equal(t, "u4", result.Reassigned[0].NewReviewerID, "the least loaded candidate, a senior when one is left")
+				assert.Equal(t, c.wantMissing, result.Reassigned[0].MissingSeniors)
+			}
+			assert.Len(t, swaps, 1)
+		})
+	}
+}
+
+func TestCreateTeam_InvalidRole(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(model.Team{}, model.ErrNotFound)
+
+	_, err := service.CreateTeam(context.Background(), model.Team{
+		TeamName: "backend",
+		Members:  []model.TeamMember{{UserID: "u1", Username: "Alice", IsActive: true, Role: "boss"}},
+	})
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.InvalidArgument, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "CreateTeam", mock.Anything, mock.Anything)
+}
+
+func TestSetMemberRole(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("SetUserRole", mock.Anything, "backend", "u1", model.RoleLead).Return(model.User{UserID: "u1", TeamName: "backend", Role: model.RoleLead}, nil)
+	mockRepo.On("SetUserRole", mock.Anything, "backend", "u9", model.RoleSenior).Return(model.User{}, model.ErrNotFound)
+
+	u, err := service.SetMemberRole(context.Background(), "backend", "u1", model.RoleLead)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleLead, u.Role)
+
+	_, err = service.SetMemberRole(context.Background(), "backend", "u9", model.RoleSenior)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotFound, apiErr.Code)
+
+	_, err = service.SetMemberRole(context.Background(), "backend", "u1", "")
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.InvalidArgument, apiErr.Code)
+	mockRepo.AssertNumberOfCalls(t, "SetUserRole", 2)
+}
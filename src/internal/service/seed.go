@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// SeedSource hands out the seeds assignments are made with. The service shares one source
+// across concurrent requests, so implementations must be safe for concurrent use.
+type SeedSource interface {
+	// Derive returns the seed of the next assignment on the PR prID. A dryRun only peeks at that seed,
+	// so previews do not change the seeds of later assignments.
+	Derive(prID string, dryRun bool) int64
+}
+
+// lockedSeedSource derives per-PR seeds from a base seed. A service started with the same base
+// seed that serves the same requests in the same order assigns the same reviewers.
+type lockedSeedSource struct {
+	mu   sync.Mutex
+	rnd  *rand.Rand
+	next int64
+}
+
+func NewSeedSource(seed int64) SeedSource {
+	rnd := rand.New(rand.NewSource(seed))
+	return &lockedSeedSource{rnd: rnd, next: rnd.Int63()}
+}
+
+// Derive mixes the next value of the base sequence with a hash of the PR id. Every assignment takes
+// its own value under the lock, so concurrent assignments get independent seeds, though two of them
+// may still end up with the same seed by chance.
+func (s *lockedSeedSource) Derive(prID string, dryRun bool) int64 {
+	s.mu.Lock()
+	n := s.next
+	if !dryRun {
+		s.next = s.rnd.Int63()
+	}
+	s.mu.Unlock()
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(prID))
+	return n ^ int64(h.Sum64()&math.MaxInt64)
+}
+
+// subSeed derives the seed of the i-th sub-pick of stage from the seed of the whole pick.
+func subSeed(seed int64, stage string, i int) int64 {
+	var b [16]byte
+	binary.LittleEndian.PutUint64(b[:8], uint64(seed))
+	binary.LittleEndian.PutUint64(b[8:], uint64(i))
+
+	h := fnv.New64a()
+	_, _ = h.Write(b[:])
+	_, _ = h.Write([]byte(stage))
+	return int64(h.Sum64() & math.MaxInt64)
+}
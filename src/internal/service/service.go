@@ -3,19 +3,86 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
 	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
 	"github.com/ce-fello/pr-reviewer-service/src/internal/store"
-	"math/rand"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+const DefaultReviewersCount = 2
+
 type Service struct {
-	repo store.Repository
-	log  *zap.Logger
-	rnd  *rand.Rand
+	repo       store.Repository
+	log        *zap.Logger
+	strategy   AssignmentStrategy
+	strategies map[string]AssignmentStrategy
+	seeds      SeedSource
+
+	requiredApprovals int
+	capacityOverflow  string
+}
+
+type Option func(*Service)
+
+// WithStrategy overrides the default random assignment strategy.
+func WithStrategy(strategy AssignmentStrategy) Option {
+	return func(s *Service) {
+		s.strategy = strategy
+		s.strategies[strategy.Name()] = strategy
+	}
+}
+
+// WithStrategies registers strategies teams can select by name in their settings.
+func WithStrategies(strategies ...AssignmentStrategy) Option {
+	return func(s *Service) {
+		for _, st := range strategies {
+			s.strategies[st.Name()] = st
+		}
+	}
+}
+
+// WithSeed makes assignments reproducible: every assignment seed is derived from seed.
+func WithSeed(seed int64) Option {
+	return WithSeedSource(NewSeedSource(seed))
+}
+
+// WithSeedSource overrides the source of assignment seeds, which is seeded from the clock by default.
+func WithSeedSource(src SeedSource) Option {
+	return func(s *Service) {
+		s.seeds = src
+	}
+}
+
+// WithRequiredApprovals sets the number of approvals every PR needs before it can be merged.
+// Teams can require more in their settings, zero disables the global requirement.
+func WithRequiredApprovals(n int) Option {
+	return func(s *Service) {
+		s.requiredApprovals = n
+	}
+}
+
+// WithCapacityOverflow sets what happens when a team is short of reviewers because its members
+// are at capacity, one of the CapacityOverflow modes. The default is CapacityOverflowFallback.
+func WithCapacityOverflow(mode string) Option {
+	return func(s *Service) {
+		s.capacityOverflow = mode
+	}
+}
+
+// TeamSettingsUpdate lists the settings to change, nil fields are left as is.
+type TeamSettingsUpdate struct {
+	ReviewersCount     *int      `json:"reviewers_count"`
+	MinReviewers       *int      `json:"min_reviewers"`
+	Strategy           *string   `json:"strategy"`
+	RequiredApprovals  *int      `json:"required_approvals"`
+	FallbackTeams      *[]string `json:"fallback_teams"`
+	AntiAffinityWindow *int      `json:"anti_affinity_window"`
+	MinSeniorReviewers *int      `json:"min_senior_reviewers"`
+	SeniorPolicy       *string   `json:"senior_policy"`
 }
 
 type Stats struct {
@@ -23,13 +90,21 @@ type Stats struct {
 	PRAssignments   map[string]int `json:"pr_assignments"`
 }
 
-func NewService(repos store.Repository, logger *zap.Logger) *Service {
-	src := rand.NewSource(time.Now().UnixNano())
-	return &Service{
-		repo: repos,
-		log:  logger,
-		rnd:  rand.New(src),
+func NewService(repos store.Repository, logger *zap.Logger, opts ...Option) *Service {
+	random := NewRandomStrategy()
+	s := &Service{
+		repo:       repos,
+		log:        logger,
+		strategy:   random,
+		strategies: map[string]AssignmentStrategy{random.Name(): random},
+		seeds:      NewSeedSource(time.Now().UnixNano()),
+
+		capacityOverflow: CapacityOverflowFallback,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 func (s *Service) CreateTeam(ctx context.Context, t model.Team) (model.Team, error) {
@@ -37,6 +112,17 @@ func (s *Service) CreateTeam(ctx context.Context, t model.Team) (model.Team, err
 		return model.Team{}, apiErrors.APIError{Code: apiErrors.TeamExists, Message: "team_name already exists"}
 	}
 
+	members := make([]model.TeamMember, len(t.Members))
+	for i, m := range t.Members {
+		role, err := normalizeRole(m.Role)
+		if err != nil {
+			return model.Team{}, err
+		}
+		m.Role = role
+		members[i] = m
+	}
+	t.Members = members
+
 	for _, m := range t.Members {
 		if _, err := s.repo.GetUser(ctx, m.UserID); err == nil {
 			return model.Team{}, apiErrors.APIError{Code: apiErrors.TeamExists, Message: "user_id " + m.UserID + " already exists"}
@@ -61,6 +147,97 @@ func (s *Service) GetTeam(ctx context.Context, teamName string) (model.Team, err
 	return t, nil
 }
 
+func (s *Service) GetTeamSettings(ctx context.Context, teamName string) (model.TeamSettings, error) {
+	if _, err := s.GetTeam(ctx, teamName); err != nil {
+		return model.TeamSettings{}, err
+	}
+	return s.teamSettings(ctx, teamName)
+}
+
+func (s *Service) UpdateTeamSettings(ctx context.Context, teamName string, upd TeamSettingsUpdate) (model.TeamSettings, error) {
+	settings, err := s.GetTeamSettings(ctx, teamName)
+	if err != nil {
+		return model.TeamSettings{}, err
+	}
+	if upd.ReviewersCount != nil {
+		settings.ReviewersCount = *upd.ReviewersCount
+	}
+	if upd.MinReviewers != nil {
+		settings.MinReviewers = *upd.MinReviewers
+	}
+	if upd.Strategy != nil {
+		settings.Strategy = *upd.Strategy
+	}
+	if upd.FallbackTeams != nil {
+		settings.FallbackTeams = *upd.FallbackTeams
+	}
+	if upd.RequiredApprovals != nil {
+		settings.RequiredApprovals = *upd.RequiredApprovals
+	}
+	if upd.AntiAffinityWindow != nil {
+		settings.AntiAffinityWindow = *upd.AntiAffinityWindow
+	}
+	if upd.MinSeniorReviewers != nil {
+		settings.MinSeniorReviewers = *upd.MinSeniorReviewers
+	}
+	if upd.SeniorPolicy != nil {
+		settings.SeniorPolicy = *upd.SeniorPolicy
+	}
+
+	if settings.ReviewersCount < 0 || settings.MinReviewers < 0 || settings.RequiredApprovals < 0 || settings.MinSeniorReviewers < 0 {
+		return model.TeamSettings{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "reviewer counts must not be negative"}
+	}
+	if settings.AntiAffinityWindow < 0 {
+		return model.TeamSettings{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "anti_affinity_window must not be negative"}
+	}
+	if settings.MinReviewers > settings.ReviewersCount {
+		return model.TeamSettings{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "min_reviewers must not exceed reviewers_count"}
+	}
+	if settings.MinSeniorReviewers > settings.ReviewersCount {
+		return model.TeamSettings{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "min_senior_reviewers must not exceed reviewers_count"}
+	}
+	switch settings.SeniorPolicy {
+	case "", SeniorPolicyFail, SeniorPolicyWarn:
+	default:
+		return model.TeamSettings{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "senior_policy must be fail or warn"}
+	}
+	if _, ok := s.strategies[settings.Strategy]; settings.Strategy != "" && !ok {
+		return model.TeamSettings{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "unknown strategy " + settings.Strategy}
+	}
+	seen := make(map[string]bool, len(settings.FallbackTeams))
+	for _, fallback := range settings.FallbackTeams {
+		if fallback == teamName || seen[fallback] {
+			return model.TeamSettings{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "fallback_teams must be distinct and must not include the team itself"}
+		}
+		seen[fallback] = true
+		if _, err := s.GetTeam(ctx, fallback); err != nil {
+			return model.TeamSettings{}, err
+		}
+	}
+
+	return s.repo.UpsertTeamSettings(ctx, settings)
+}
+
+// teamSettings returns the stored settings of a team or the defaults if none were saved.
+func (s *Service) teamSettings(ctx context.Context, teamName string) (model.TeamSettings, error) {
+	settings, err := s.repo.GetTeamSettings(ctx, teamName)
+	if errors.Is(err, model.ErrNotFound) {
+		return model.TeamSettings{TeamName: teamName, ReviewersCount: DefaultReviewersCount}, nil
+	}
+	return settings, err
+}
+
+// strategyFor resolves a strategy name from team settings, falling back to the default one.
+func (s *Service) strategyFor(name string) AssignmentStrategy {
+	if st, ok := s.strategies[name]; ok {
+		return st
+	}
+	if name != "" {
+		s.log.Warn("unknown assignment strategy, using default", zap.String("strategy", name))
+	}
+	return s.strategy
+}
+
 func (s *Service) SetUserIsActive(ctx context.Context, userID string, isActive bool) (model.User, error) {
 	u, err := s.repo.SetUserIsActive(ctx, userID, isActive)
 	if err != nil {
@@ -72,40 +249,614 @@ func (s *Service) SetUserIsActive(ctx context.Context, userID string, isActive b
 	return u, nil
 }
 
-func (s *Service) CreatePR(ctx context.Context, prID, prName, authorID string) (model.PullRequest, error) {
-	author, err := s.repo.GetUser(ctx, authorID)
+// Reassignment is a reviewer swap done on behalf of an unavailable reviewer.
+type Reassignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+	// MissingSeniors is how many senior reviewers the PR lacks after the swap under the warn policy.
+	MissingSeniors int `json:"missing_seniors,omitempty"`
+}
+
+// FailedReassignment is an open PR whose reviewer could not be replaced.
+type FailedReassignment struct {
+	PullRequestID string              `json:"pull_request_id"`
+	Code          apiErrors.ErrorCode `json:"code"`
+	Message       string              `json:"message"`
+}
+
+type ReassignmentReport struct {
+	Reassigned []Reassignment       `json:"reassigned"`
+	Failed     []FailedReassignment `json:"failed"`
+}
+
+// ReassignOpenReviews replaces the user on every OPEN PR they review, using the same
+// candidate rules as ReassignReviewer. Each PR is reassigned on its own, so PRs that cannot
+// be reassigned are reported, not fatal, and keep the user as a reviewer. Calling it again
+// for the same user retries only those PRs.
+func (s *Service) ReassignOpenReviews(ctx context.Context, userID string) (ReassignmentReport, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return ReassignmentReport{}, err
+	}
+	prs, err := s.repo.GetAssignedPRsForUser(ctx, userID)
 	if err != nil {
-		return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "author not found"}
+		return ReassignmentReport{}, err
 	}
 
-	if _, err := s.repo.GetPR(ctx, prID); err == nil {
-		return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.PRExists, Message: "PR id already exists"}
-	} else if !errors.Is(err, model.ErrNotFound) {
-		return model.PullRequest{}, err
+	report := ReassignmentReport{Reassigned: []Reassignment{}, Failed: []FailedReassignment{}}
+	for _, pr := range prs {
+		if pr.Status != model.StatusOpen {
+			continue
+		}
+		_, newReviewer, err := s.ReassignReviewer(ctx, pr.PullRequestID, userID, "", 0)
+		if err != nil {
+			failed := FailedReassignment{PullRequestID: pr.PullRequestID, Code: apiErrors.InternalError, Message: err.Error()}
+			var e apiErrors.APIError
+			if errors.As(err, &e) {
+				failed.Code, failed.Message = e.Code, e.Message
+			}
+			s.log.Warn("ReassignOpenReviews: reassign failed", zap.String("pr_id", pr.PullRequestID), zap.String("user", userID), zap.Error(err))
+			report.Failed = append(report.Failed, failed)
+			continue
+		}
+		report.Reassigned = append(report.Reassigned, Reassignment{
+			PullRequestID: pr.PullRequestID,
+			OldReviewerID: userID,
+			NewReviewerID: newReviewer,
+		})
+	}
+	return report, nil
+}
+
+type BulkDeactivationResult struct {
+	TeamName    string   `json:"team_name"`
+	Deactivated []string `json:"deactivated"`
+	ReassignmentReport
+}
+
+// DeactivateTeamUsers deactivates several team members at once and hands their open reviews
+// over to the remaining active teammates (or fallback teams) in a single transaction.
+// Replacements are picked with the same strategy as ReassignReviewer uses. Candidates and loads are
+// loaded upfront, only the rules a PR or the team enables (skills, anti-affinity, seniors) query per PR.
+// Open review limits count the reviews handed out by the batch itself. A swap breaking the senior
+// policy of the author's team is reported as failed and leaves the reviewer in place.
+func (s *Service) DeactivateTeamUsers(ctx context.Context, teamName string, userIDs []string) (BulkDeactivationResult, error) {
+	team, err := s.GetTeam(ctx, teamName)
+	if err != nil {
+		return BulkDeactivationResult{}, err
+	}
+	members := make(map[string]bool, len(team.Members))
+	for _, m := range team.Members {
+		members[m.UserID] = true
+	}
+	leaving := make(map[string]bool, len(userIDs))
+	var ids []string
+	for _, id := range userIDs {
+		if !members[id] {
+			return BulkDeactivationResult{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "user_id " + id + " is not a member of team " + teamName}
+		}
+		if !leaving[id] {
+			leaving[id] = true
+			ids = append(ids, id)
+		}
 	}
 
-	candidates, err := s.repo.GetActiveTeamMembersExcept(ctx, author.TeamName, authorID)
+	settings, err := s.teamSettings(ctx, teamName)
 	if err != nil {
-		return model.PullRequest{}, err
+		return BulkDeactivationResult{}, err
+	}
+
+	active, err := s.repo.GetActiveTeamMembersExcept(ctx, teamName, "")
+	if err != nil {
+		return BulkDeactivationResult{}, err
+	}
+	pools := [][]string{without(active, ids)}
+	poolTeams := append([]string{teamName}, settings.FallbackTeams...)
+	for _, fb := range settings.FallbackTeams {
+		members, err := s.repo.GetActiveTeamMembersExcept(ctx, fb, "")
+		if err != nil {
+			return BulkDeactivationResult{}, err
+		}
+		pools = append(pools, without(members, ids))
+	}
+
+	var everyone []string
+	for _, pool := range pools {
+		everyone = append(everyone, pool...)
+	}
+	loads, err := s.repo.GetOpenReviewCounts(ctx, everyone)
+	if err != nil {
+		return BulkDeactivationResult{}, err
+	}
+	limits, err := s.repo.GetMaxOpenReviews(ctx, everyone)
+	if err != nil {
+		return BulkDeactivationResult{}, err
+	}
+
+	prs, err := s.repo.GetOpenPRsForReviewers(ctx, ids)
+	if err != nil {
+		return BulkDeactivationResult{}, err
+	}
+
+	result := BulkDeactivationResult{
+		TeamName:           teamName,
+		Deactivated:        ids,
+		ReassignmentReport: ReassignmentReport{Reassigned: []Reassignment{}, Failed: []FailedReassignment{}},
+	}
+	var swaps []model.ReviewerSwap
+	authorTeams := make(map[string]string)
+	policies := make(map[string]model.TeamSettings)
+	for _, pr := range prs {
+		author := model.User{UserID: pr.AuthorID}
+		authorTeam, ok := authorTeams[pr.AuthorID]
+		if !ok {
+			authorTeam = teamName
+			if !members[pr.AuthorID] {
+				u, err := s.getUser(ctx, pr.AuthorID)
+				if err != nil {
+					return BulkDeactivationResult{}, err
+				}
+				authorTeam = u.TeamName
+			}
+			authorTeams[pr.AuthorID] = authorTeam
+		}
+		policy, ok := policies[authorTeam]
+		if !ok {
+			if policy, err = s.withSeniorPolicyOf(ctx, authorTeam, teamName, settings); err != nil {
+				return BulkDeactivationResult{}, err
+			}
+			policies[authorTeam] = policy
+		}
+		strategy := s.assignmentStrategy(policy, pr)
+		reviewers := append([]string(nil), pr.Assigned...)
+		for _, old := range pr.Assigned {
+			if !leaving[old] {
+				continue
+			}
+			seed := s.seeds.Derive(pr.PullRequestID, false)
+			base := AssignmentRequest{PR: pr, Author: author, Loads: loads, Seed: seed}
+			// seniors who are leaving as well do not count
+			if base.MinSeniors, err = s.seniorsMissing(ctx, policy.MinSeniorReviewers, without(reviewers, ids)); err != nil {
+				return BulkDeactivationResult{}, err
+			}
+			if len(pr.RequiredSkills) > 0 {
+				// skills of reviewers who are leaving as well do not count
+				if base.RequiredSkills, err = s.uncoveredSkills(ctx, pr.RequiredSkills, without(pr.Assigned, ids)); err != nil {
+					return BulkDeactivationResult{}, err
+				}
+			}
+			picked, pool, err := s.pickBulkReplacement(ctx, strategy, base, teamName, pools, limits)
+			if err != nil {
+				var e apiErrors.APIError
+				if !errors.As(err, &e) {
+					return BulkDeactivationResult{}, err
+				}
+				result.Failed = append(result.Failed, FailedReassignment{PullRequestID: pr.PullRequestID, Code: e.Code, Message: e.Message})
+				continue
+			}
+			if len(picked) == 0 {
+				result.Failed = append(result.Failed, FailedReassignment{
+					PullRequestID: pr.PullRequestID,
+					Code:          apiErrors.NoCandidate,
+					Message:       "no active replacement candidate for " + old,
+				})
+				continue
+			}
+
+			newReviewer := picked[0]
+			after := append(without(reviewers, []string{old}), newReviewer)
+			missing, err := s.seniorsAfterChange(ctx, policy, reviewers, after)
+			if err != nil {
+				var e apiErrors.APIError
+				if !errors.As(err, &e) {
+					return BulkDeactivationResult{}, err
+				}
+				result.Failed = append(result.Failed, FailedReassignment{PullRequestID: pr.PullRequestID, Code: e.Code, Message: e.Message})
+				continue
+			}
+			reviewers = after
+			loads[newReviewer]++
+			pr.Assigned = append(pr.Assigned, newReviewer)
+			// fallback status is relative to the author's team, not to the team being deactivated
+			fromFallback := poolTeams[pool] != authorTeam
+			swaps = append(swaps, model.ReviewerSwap{
+				PullRequestID: pr.PullRequestID,
+				OldUserID:     old,
+				NewUserID:     newReviewer,
+				IsFallback:    fromFallback,
+				Version:       pr.Version,
+				Assignment:    model.AssignmentInfo{Strategy: strategy.Name(), Seed: seed},
+			})
+			result.Reassigned = append(result.Reassigned, Reassignment{
+				PullRequestID:  pr.PullRequestID,
+				OldReviewerID:  old,
+				NewReviewerID:  newReviewer,
+				MissingSeniors: missing,
+			})
+		}
+	}
+
+	if err := s.repo.DeactivateUsersAndReassign(ctx, teamName, ids, swaps); err != nil {
+		if errors.Is(err, model.ErrConflict) {
+			return BulkDeactivationResult{}, apiErrors.APIError{Code: apiErrors.Conflict, Message: "team or PRs changed during deactivation, retry the request"}
+		}
+		return BulkDeactivationResult{}, err
+	}
+	return result, nil
+}
+
+// pickBulkReplacement picks the replacement of a leaving reviewer of base.PR from pools, the team
+// first and its fallback teams in order. Candidates whose base.Loads reached their limit are skipped,
+// if that leaves the team without a candidate the capacity overflow mode decides. It also returns
+// the index of the pool the replacement comes from.
+func (s *Service) pickBulkReplacement(ctx context.Context, strategy AssignmentStrategy, base AssignmentRequest,
+	team string, pools [][]string, limits map[string]int) ([]string, int, error) {
+	taken := append(append([]string(nil), base.PR.Assigned...), base.PR.AuthorID)
+	for i, pool := range pools {
+		var free, full []string
+		for _, id := range without(pool, taken) {
+			if limit, ok := limits[id]; ok && base.Loads[id] >= limit {
+				full = append(full, id)
+			} else {
+				free = append(free, id)
+			}
+		}
+		req := base.stage("pool", i)
+		req.Candidates, req.Count = free, 1
+		var picked []string
+		if len(free) > 0 {
+			var err error
+			if picked, err = strategy.Pick(ctx, req); err != nil {
+				return nil, 0, err
+			}
+		}
+		if len(picked) == 0 && i == 0 {
+			var err error
+			if picked, err = s.overflow(ctx, req, full, 1, nil, team, nil); err != nil {
+				return nil, 0, err
+			}
+		}
+		if len(picked) > 0 {
+			return picked, i, nil
+		}
+	}
+	return nil, 0, nil
+}
+
+// AbsenceUpdate lists the absence fields to change, nil fields are left as is.
+type AbsenceUpdate struct {
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	Reason   *string    `json:"reason"`
+}
+
+func (s *Service) AddAbsence(ctx context.Context, a model.Absence) (model.Absence, error) {
+	if _, err := s.getUser(ctx, a.UserID); err != nil {
+		return model.Absence{}, err
+	}
+	if !a.EndsAt.After(a.StartsAt) {
+		return model.Absence{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "ends_at must be after starts_at"}
+	}
+	return s.repo.CreateAbsence(ctx, a)
+}
+
+func (s *Service) ListAbsences(ctx context.Context, userID string) ([]model.Absence, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAbsences(ctx, userID)
+}
+
+func (s *Service) UpdateAbsence(ctx context.Context, absenceID int64, upd AbsenceUpdate) (model.Absence, error) {
+	a, err := s.repo.GetAbsence(ctx, absenceID)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return model.Absence{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "absence not found"}
+		}
+		return model.Absence{}, err
+	}
+	if upd.StartsAt != nil {
+		a.StartsAt = *upd.StartsAt
+	}
+	if upd.EndsAt != nil {
+		a.EndsAt = *upd.EndsAt
+	}
+	if upd.Reason != nil {
+		a.Reason = *upd.Reason
+	}
+	if !a.EndsAt.After(a.StartsAt) {
+		return model.Absence{}, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "ends_at must be after starts_at"}
+	}
+
+	a, err = s.repo.UpdateAbsence(ctx, a)
+	if errors.Is(err, model.ErrNotFound) {
+		return model.Absence{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "absence not found"}
+	}
+	return a, err
+}
+
+func (s *Service) DeleteAbsence(ctx context.Context, absenceID int64) error {
+	err := s.repo.DeleteAbsence(ctx, absenceID)
+	if errors.Is(err, model.ErrNotFound) {
+		return apiErrors.APIError{Code: apiErrors.NotFound, Message: "absence not found"}
+	}
+	return err
+}
+
+func (s *Service) getUser(ctx context.Context, userID string) (model.User, error) {
+	u, err := s.repo.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return model.User{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "user not found"}
+		}
+		return model.User{}, err
+	}
+	return u, nil
+}
+
+// CreatePRRequest describes a PR to create. Draft PRs get no reviewers until they are marked ready,
+// Explain asks for an explanation of the reviewer pick. ChangedFiles route the PR to the code owners of those paths,
+// reviewers covering RequiredSkills are preferred.
+type CreatePRRequest struct {
+	PullRequestID   string
+	PullRequestName string
+	AuthorID        string
+	ChangedFiles    []string
+	RequiredSkills  []string
+	Draft           bool
+	Explain         bool
+}
+
+func (s *Service) CreatePR(ctx context.Context, prID, prName, authorID string) (model.PullRequest, error) {
+	pr, _, err := s.CreatePRExplained(ctx, CreatePRRequest{PullRequestID: prID, PullRequestName: prName, AuthorID: authorID})
+	return pr, err
+}
+
+// CreateDraftPR stores a DRAFT PR, reviewers are assigned once it is marked ready.
+func (s *Service) CreateDraftPR(ctx context.Context, prID, prName, authorID string) (model.PullRequest, error) {
+	pr, _, err := s.CreatePRExplained(ctx, CreatePRRequest{PullRequestID: prID, PullRequestName: prName, AuthorID: authorID, Draft: true})
+	return pr, err
+}
+
+// CreatePRExplained creates the PR described by req. The explanation is only returned
+// if req.Explain is set and reviewers were assigned.
+func (s *Service) CreatePRExplained(ctx context.Context, req CreatePRRequest) (model.PullRequest, *Explanation, error) {
+	author, err := s.repo.GetUser(ctx, req.AuthorID)
+	if err != nil {
+		return model.PullRequest{}, nil, apiErrors.APIError{Code: apiErrors.NotFound, Message: "author not found"}
+	}
+	changedFiles, err := normalizePaths(req.ChangedFiles)
+	if err != nil {
+		return model.PullRequest{}, nil, err
+	}
+	requiredSkills, err := normalizeSkills(req.RequiredSkills)
+	if err != nil {
+		return model.PullRequest{}, nil, err
+	}
+
+	if _, err := s.repo.GetPR(ctx, req.PullRequestID); err == nil {
+		return model.PullRequest{}, nil, apiErrors.APIError{Code: apiErrors.PRExists, Message: "PR id already exists"}
+	} else if !errors.Is(err, model.ErrNotFound) {
+		return model.PullRequest{}, nil, err
 	}
-	selected := chooseUpToN(s.rnd, candidates, 2)
 
 	pr := model.PullRequest{
-		PullRequestID:   prID,
-		PullRequestName: prName,
-		AuthorID:        authorID,
-		Status:          "OPEN",
-		Assigned:        selected,
+		PullRequestID:   req.PullRequestID,
+		PullRequestName: req.PullRequestName,
+		AuthorID:        req.AuthorID,
+		Status:          model.StatusOpen,
 		CreatedAt:       time.Now().UTC(),
+		Version:         1, // pull_requests.version default
+		Reviewers:       []model.ReviewerState{},
+		ChangedFiles:    changedFiles,
+		RequiredSkills:  requiredSkills,
+	}
+	var ex *Explanation
+	if req.Draft {
+		pr.Status = model.StatusDraft
+	} else if ex, err = s.assignReviewers(ctx, &pr, author, assignOptions{explain: req.Explain}); err != nil {
+		return model.PullRequest{}, nil, err
 	}
 
 	if err := s.repo.CreatePRWithReviewers(ctx, pr); err != nil {
-		return model.PullRequest{}, err
+		return model.PullRequest{}, nil, err
 	}
-	return pr, nil
+	return pr, ex, nil
+}
+
+// Preview is the outcome of a dry-run assignment, nothing is persisted.
+// Eligible lists every available candidate of the author's team and its fallback teams.
+type Preview struct {
+	AuthorID       string       `json:"author_id"`
+	TeamName       string       `json:"team_name"`
+	Strategy       string       `json:"strategy"`
+	Seed           int64        `json:"seed,string"`
+	Reviewers      []string     `json:"reviewers"`
+	Fallback       []string     `json:"fallback_reviewers"`
+	Eligible       []string     `json:"eligible"`
+	Uncovered      []string     `json:"uncovered_skills,omitempty"`
+	MissingSeniors int          `json:"missing_seniors,omitempty"`
+	Explanation    *Explanation `json:"explanation,omitempty"`
 }
 
-func (s *Service) MergePR(ctx context.Context, prID string) (model.PullRequest, error) {
+// PreviewPR runs the reviewer selection of CreatePRExplained for req without storing the PR
+// or advancing strategy state.
+func (s *Service) PreviewPR(ctx context.Context, req CreatePRRequest) (Preview, error) {
+	author, err := s.repo.GetUser(ctx, req.AuthorID)
+	if err != nil {
+		return Preview{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "author not found"}
+	}
+	changedFiles, err := normalizePaths(req.ChangedFiles)
+	if err != nil {
+		return Preview{}, err
+	}
+	requiredSkills, err := normalizeSkills(req.RequiredSkills)
+	if err != nil {
+		return Preview{}, err
+	}
+
+	pr := model.PullRequest{
+		PullRequestID:   req.PullRequestID,
+		PullRequestName: req.PullRequestName,
+		AuthorID:        req.AuthorID,
+		Status:          model.StatusOpen,
+		ChangedFiles:    changedFiles,
+		RequiredSkills:  requiredSkills,
+	}
+	ex, err := s.assignReviewers(ctx, &pr, author, assignOptions{explain: req.Explain, dryRun: true})
+	if err != nil {
+		return Preview{}, err
+	}
+
+	settings, err := s.teamSettings(ctx, author.TeamName)
+	if err != nil {
+		return Preview{}, err
+	}
+	eligible := []string{}
+	for _, team := range append([]string{author.TeamName}, settings.FallbackTeams...) {
+		members, err := s.repo.GetActiveTeamMembersExcept(ctx, team, author.UserID)
+		if err != nil {
+			return Preview{}, err
+		}
+		eligible = append(eligible, without(members, eligible)...)
+	}
+
+	return Preview{
+		AuthorID:       author.UserID,
+		TeamName:       author.TeamName,
+		Strategy:       pr.Assignment.Strategy,
+		Seed:           pr.Assignment.Seed,
+		Reviewers:      append([]string{}, pr.Assigned...),
+		Fallback:       append([]string{}, pr.Fallback...),
+		Eligible:       eligible,
+		Uncovered:      pr.UncoveredSkills,
+		MissingSeniors: pr.MissingSeniors,
+		Explanation:    ex,
+	}, nil
+}
+
+// assignOptions tune a reviewer assignment: explain records how reviewers were picked,
+// dryRun keeps strategies from updating their state.
+type assignOptions struct {
+	explain bool
+	dryRun  bool
+}
+
+// assignReviewers picks reviewers for pr following the author's team settings and fallback teams.
+// Code owners of the changed files are picked first, the remaining slots are filled from the
+// author's team, preferring reviewers with the required skills. Members at capacity are skipped,
+// if that leaves the team short the capacity overflow mode decides. Every stage picks the senior
+// reviewers the team still requires first, falling short of them fails or is left in pr.MissingSeniors
+// as the team's senior policy says. Skills nobody covers are left in pr.UncoveredSkills.
+// With opts.explain set it also returns how the reviewers were picked.
+func (s *Service) assignReviewers(ctx context.Context, pr *model.PullRequest, author model.User, opts assignOptions) (*Explanation, error) {
+	settings, err := s.teamSettings(ctx, author.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.repo.GetActiveTeamMembersExcept(ctx, author.TeamName, author.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := s.assignmentStrategy(settings, *pr)
+	var ex *Explanation
+	if opts.explain {
+		ex = newExplanation(strategy.Name(), settings.ReviewersCount)
+	}
+	base := AssignmentRequest{
+		PR: *pr, Author: author, DryRun: opts.dryRun, Seed: s.seeds.Derive(pr.PullRequestID, opts.dryRun),
+		RequiredSkills: pr.RequiredSkills, MinSeniors: settings.MinSeniorReviewers,
+	}
+	if ex != nil {
+		ex.Seed = base.Seed
+	}
+	owners, err := s.pickOwners(ctx, strategy, base, ex)
+	if err != nil {
+		return nil, err
+	}
+	excluded := exclusions(*pr, owners)
+	free, full, err := s.withoutFull(ctx, without(candidates, owners), excluded)
+	if err != nil {
+		return nil, err
+	}
+	req := base.stage("team", 0)
+	req.Candidates, req.Count = free, settings.ReviewersCount-len(owners)
+	if req.RequiredSkills, err = s.uncoveredSkills(ctx, pr.RequiredSkills, owners); err != nil {
+		return nil, err
+	}
+	if req.MinSeniors, err = s.seniorsMissing(ctx, settings.MinSeniorReviewers, owners); err != nil {
+		return nil, err
+	}
+	selected, err := s.pick(ctx, strategy, req, ex, author.TeamName, false, excluded)
+	if err != nil {
+		return nil, err
+	}
+	if short := req.Count - len(selected); short > 0 && len(full) > 0 {
+		if req.RequiredSkills, err = s.uncoveredSkills(ctx, req.RequiredSkills, selected); err != nil {
+			return nil, err
+		}
+		if req.MinSeniors, err = s.seniorsMissing(ctx, req.MinSeniors, selected); err != nil {
+			return nil, err
+		}
+		overflow, err := s.overflow(ctx, req, full, short, ex, author.TeamName, exclusions(*pr, append(owners, selected...)))
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, overflow...)
+	}
+	selected = append(owners, selected...)
+
+	var fallback []string
+	if missing := settings.ReviewersCount - len(selected); missing > 0 {
+		if base.RequiredSkills, err = s.uncoveredSkills(ctx, pr.RequiredSkills, selected); err != nil {
+			return nil, err
+		}
+		if base.MinSeniors, err = s.seniorsMissing(ctx, settings.MinSeniorReviewers, selected); err != nil {
+			return nil, err
+		}
+		fallback, err = s.pickFromFallback(ctx, strategy, base, settings.FallbackTeams, selected, missing, ex)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, fallback...)
+	}
+	if len(selected) < settings.MinReviewers {
+		return nil, apiErrors.APIError{
+			Code:    apiErrors.NotEnoughReviewers,
+			Message: fmt.Sprintf("team requires at least %d reviewers, %d available", settings.MinReviewers, len(selected)),
+		}
+	}
+	missingSeniors, err := s.seniorsMissing(ctx, settings.MinSeniorReviewers, selected)
+	if err != nil {
+		return nil, err
+	}
+	if pr.MissingSeniors, err = enforceSeniors(settings, missingSeniors); err != nil {
+		return nil, err
+	}
+
+	pr.Assigned = selected
+	pr.Fallback = fallback
+	pr.Reviewers = pendingReviewers(selected)
+	pr.Assignment = model.AssignmentInfo{Strategy: strategy.Name(), Seed: base.Seed}
+	if pr.UncoveredSkills, err = s.uncoveredSkills(ctx, pr.RequiredSkills, selected); err != nil {
+		return nil, err
+	}
+	return ex, nil
+}
+
+// MergeGateDetails explains why a PR does not pass the merge gate yet.
+type MergeGateDetails struct {
+	RequiredApprovals  int      `json:"required_approvals"`
+	Approvals          int      `json:"approvals"`
+	MissingApprovals   int      `json:"missing_approvals"`
+	AwaitingApproval   []string `json:"awaiting_approval"`
+	ChangesRequestedBy []string `json:"changes_requested_by"`
+}
+
+// MergePR marks the PR as MERGED once it passes the merge gate, force skips the gate and is recorded on the PR.
+func (s *Service) MergePR(ctx context.Context, prID string, ifVersion int64, force bool) (model.PullRequest, error) {
 	pr, err := s.repo.GetPR(ctx, prID)
 	if err != nil {
 		if errors.Is(err, model.ErrNotFound) {
@@ -113,29 +864,108 @@ func (s *Service) MergePR(ctx context.Context, prID string) (model.PullRequest,
 		}
 		return model.PullRequest{}, err
 	}
-	if pr.Status == "MERGED" {
+	if err := checkVersion(pr, ifVersion); err != nil {
+		return model.PullRequest{}, err
+	}
+	if pr.Status == model.StatusMerged {
 		return pr, nil
 	}
-	pr.Status = "MERGED"
+	if err := checkTransition(ActionMerge, pr.Status); err != nil {
+		return model.PullRequest{}, err
+	}
+	if !force {
+		if err := s.checkMergeGate(ctx, pr); err != nil {
+			return model.PullRequest{}, err
+		}
+	}
+	pr.Status = model.StatusMerged
 	now := time.Now().UTC()
 	pr.MergedAt = &now
+	pr.ForceMerged = force
 
 	if err := s.repo.UpdatePR(ctx, pr); err != nil {
-		return model.PullRequest{}, err
+		if !errors.Is(err, model.ErrConflict) {
+			return model.PullRequest{}, err
+		}
+		if ifVersion != 0 {
+			return model.PullRequest{}, preconditionFailed(ifVersion)
+		}
+		// Someone else changed the PR in between: a concurrent merge keeps the call idempotent,
+		// anything else is reported as a conflict.
+		current, getErr := s.repo.GetPR(ctx, prID)
+		if getErr == nil && current.Status == model.StatusMerged {
+			return current, nil
+		}
+		return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.Conflict, Message: "PR was modified concurrently, retry the request"}
 	}
+	pr.Version++
 	return pr, nil
 }
 
-func (s *Service) ReassignReviewer(ctx context.Context, prID, oldUserID string) (model.PullRequest, string, error) {
+// checkMergeGate requires the larger of the global and the author's team approval count from the
+// currently assigned reviewers and no outstanding CHANGES_REQUESTED. Without a requirement any PR can be merged.
+func (s *Service) checkMergeGate(ctx context.Context, pr model.PullRequest) error {
+	author, err := s.repo.GetUser(ctx, pr.AuthorID)
+	if err != nil {
+		return err
+	}
+	settings, err := s.teamSettings(ctx, author.TeamName)
+	if err != nil {
+		return err
+	}
+	required := max(s.requiredApprovals, settings.RequiredApprovals)
+	if required == 0 {
+		return nil
+	}
+
+	details := MergeGateDetails{RequiredApprovals: required, AwaitingApproval: []string{}, ChangesRequestedBy: []string{}}
+	for _, r := range pr.Reviewers {
+		switch r.State {
+		case model.ReviewApproved:
+			details.Approvals++
+		case model.ReviewChangesRequested:
+			details.ChangesRequestedBy = append(details.ChangesRequestedBy, r.UserID)
+			details.AwaitingApproval = append(details.AwaitingApproval, r.UserID)
+		default:
+			details.AwaitingApproval = append(details.AwaitingApproval, r.UserID)
+		}
+	}
+	details.MissingApprovals = max(required-details.Approvals, 0)
+	if details.MissingApprovals == 0 && len(details.ChangesRequestedBy) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%d of %d required approvals", details.Approvals, required)
+	if len(details.ChangesRequestedBy) > 0 {
+		msg += fmt.Sprintf(", changes requested by %s", strings.Join(details.ChangesRequestedBy, ", "))
+	}
+	if details.MissingApprovals > 0 {
+		msg += fmt.Sprintf(", missing %d from %s", details.MissingApprovals, strings.Join(details.AwaitingApproval, ", "))
+	}
+	return apiErrors.APIError{Code: apiErrors.MergeBlocked, Message: msg, Details: details}
+}
+
+// ReassignReviewer replaces oldUserID on the PR with newUserID or, if it is empty, with an active
+// teammate of oldUserID picked by the team's strategy, falling back to the author's fallback teams.
+func (s *Service) ReassignReviewer(ctx context.Context, prID, oldUserID, newUserID string, ifVersion int64) (model.PullRequest, string, error) {
+	pr, replacedBy, _, err := s.ReassignReviewerExplained(ctx, prID, oldUserID, newUserID, ifVersion, false)
+	return pr, replacedBy, err
+}
+
+// ReassignReviewerExplained works like ReassignReviewer and with explain set also returns how the replacement was picked.
+func (s *Service) ReassignReviewerExplained(ctx context.Context, prID, oldUserID, newUserID string, ifVersion int64, explain bool) (model.PullRequest, string, *Explanation, error) {
 	pr, err := s.repo.GetPR(ctx, prID)
 	if err != nil {
 		if errors.Is(err, model.ErrNotFound) {
-			return model.PullRequest{}, "", apiErrors.APIError{Code: apiErrors.NotFound, Message: "PR not found"}
+			return model.PullRequest{}, "", nil, apiErrors.APIError{Code: apiErrors.NotFound, Message: "PR not found"}
 		}
-		return model.PullRequest{}, "", err
+		return model.PullRequest{}, "", nil, err
+	}
+	if err := checkVersion(pr, ifVersion); err != nil {
+		return model.PullRequest{}, "", nil, err
 	}
-	if pr.Status == "MERGED" {
-		return model.PullRequest{}, "", apiErrors.APIError{Code: apiErrors.PRAlreadyMerged, Message: "cannot reassign on merged PR"}
+	if err := checkTransition(ActionReassign, pr.Status); err != nil {
+		return model.PullRequest{}, "", nil, err
 	}
 
 	assigned := false
@@ -146,19 +976,51 @@ func (s *Service) ReassignReviewer(ctx context.Context, prID, oldUserID string)
 		}
 	}
 	if !assigned {
-		return model.PullRequest{}, "", apiErrors.APIError{Code: apiErrors.NotAssigned, Message: "reviewer is not assigned to this PR"}
+		return model.PullRequest{}, "", nil, apiErrors.APIError{Code: apiErrors.NotAssigned, Message: "reviewer is not assigned to this PR"}
 	}
 
 	oldUser, err := s.repo.GetUser(ctx, oldUserID)
 	if err != nil {
-		return model.PullRequest{}, "", err
+		return model.PullRequest{}, "", nil, err
+	}
+
+	author, err := s.repo.GetUser(ctx, pr.AuthorID)
+	if err != nil {
+		return model.PullRequest{}, "", nil, err
+	}
+	// fallback status and the fallback chain always follow the author's team: a fallback
+	// reviewer replaced by a teammate stays a fallback reviewer
+	settings, err := s.teamSettings(ctx, author.TeamName)
+	if err != nil {
+		return model.PullRequest{}, "", nil, err
+	}
+	if newUserID != "" {
+		isFallback, err := s.validateReviewer(ctx, pr, author.TeamName, settings.FallbackTeams, newUserID)
+		if err != nil {
+			return model.PullRequest{}, "", nil, err
+		}
+		var ex *Explanation
+		if explain {
+			ex = newExplanation(model.AssignedManually, 1)
+			ex.Picked = []string{newUserID}
+		}
+		swap := model.ReviewerSwap{
+			PullRequestID: prID,
+			OldUserID:     oldUserID,
+			NewUserID:     newUserID,
+			IsFallback:    isFallback,
+			Assignment:    model.AssignmentInfo{Strategy: model.AssignedManually},
+		}
+		return s.replaceReviewer(ctx, pr, swap, settings, ifVersion, ex)
 	}
 
 	candidates, err := s.repo.GetActiveTeamMembersExcept(ctx, oldUser.TeamName, oldUserID)
 	if err != nil {
-		return model.PullRequest{}, "", err
+		return model.PullRequest{}, "", nil, err
 	}
 
+	excluded := exclusions(pr, pr.Assigned)
+	excluded[oldUserID] = ExcludedReplaced
 	var filtered []string
 	for _, c := range candidates {
 		if c == pr.AuthorID {
@@ -175,39 +1037,245 @@ func (s *Service) ReassignReviewer(ctx context.Context, prID, oldUserID string)
 			filtered = append(filtered, c)
 		}
 	}
-	if len(filtered) == 0 {
-		return model.PullRequest{}, "", apiErrors.APIError{Code: apiErrors.NoCandidate, Message: "no active replacement candidate in team"}
+	filtered, full, err := s.withoutFull(ctx, filtered, excluded)
+	if err != nil {
+		return model.PullRequest{}, "", nil, err
+	}
+	strategy := s.assignmentStrategy(settings, pr)
+
+	base := AssignmentRequest{PR: pr, Author: author, Seed: s.seeds.Derive(prID, false)}
+	if len(pr.RequiredSkills) > 0 {
+		if base.RequiredSkills, err = s.uncoveredSkills(ctx, pr.RequiredSkills, without(pr.Assigned, []string{oldUserID})); err != nil {
+			return model.PullRequest{}, "", nil, err
+		}
+	}
+	if base.MinSeniors, err = s.seniorsMissing(ctx, settings.MinSeniorReviewers, without(pr.Assigned, []string{oldUserID})); err != nil {
+		return model.PullRequest{}, "", nil, err
+	}
+	var ex *Explanation
+	if explain {
+		ex = newExplanation(strategy.Name(), 1)
+		ex.Seed = base.Seed
+	}
+	req := base.stage("team", 0)
+	req.Candidates, req.Count = filtered, 1
+	picked, err := s.pick(ctx, strategy, req, ex, oldUser.TeamName, false, excluded)
+	if err != nil {
+		return model.PullRequest{}, "", nil, err
+	}
+	if len(picked) == 0 {
+		rest := exclusions(pr, pr.Assigned)
+		rest[oldUserID] = ExcludedReplaced
+		picked, err = s.overflow(ctx, base, full, 1, ex, oldUser.TeamName, rest)
+		if err != nil {
+			return model.PullRequest{}, "", nil, err
+		}
+	}
+	fromFallback := oldUser.TeamName != author.TeamName
+	if len(picked) == 0 {
+		fallbackTeams := without(settings.FallbackTeams, []string{oldUser.TeamName})
+		picked, err = s.pickFromFallback(ctx, strategy, base, fallbackTeams, pr.Assigned, 1, ex)
+		if err != nil {
+			return model.PullRequest{}, "", nil, err
+		}
+		fromFallback = true
+	}
+	if len(picked) == 0 {
+		return model.PullRequest{}, "", nil, apiErrors.APIError{Code: apiErrors.NoCandidate, Message: "no active replacement candidate in team"}
 	}
+	swap := model.ReviewerSwap{
+		PullRequestID: prID,
+		OldUserID:     oldUserID,
+		NewUserID:     picked[0],
+		IsFallback:    fromFallback,
+		Assignment:    model.AssignmentInfo{Strategy: strategy.Name(), Seed: base.Seed},
+	}
+	return s.replaceReviewer(ctx, pr, swap, settings, ifVersion, ex)
+}
 
-	newReviewer := filtered[s.rnd.Intn(len(filtered))]
+// replaceReviewer persists swap on pr unless it breaks the senior policy of settings.
+func (s *Service) replaceReviewer(ctx context.Context, pr model.PullRequest, swap model.ReviewerSwap, settings model.TeamSettings,
+	ifVersion int64, ex *Explanation) (model.PullRequest, string, *Explanation, error) {
+	reviewers := append(without(pr.Assigned, []string{swap.OldUserID}), swap.NewUserID)
+	missing, err := s.seniorsAfterChange(ctx, settings, pr.Assigned, reviewers)
+	if err != nil {
+		return model.PullRequest{}, "", nil, err
+	}
 
-	for i, u := range pr.Assigned {
-		if u == oldUserID {
-			pr.Assigned[i] = newReviewer
-			break
+	updated, err := s.repo.ReplaceReviewer(ctx, swap, pr.Version)
+	if err != nil {
+		if ifVersion != 0 && errors.Is(err, model.ErrConflict) {
+			return model.PullRequest{}, "", nil, preconditionFailed(ifVersion)
 		}
+		return model.PullRequest{}, "", nil, reviewerChangeError(err)
+	}
+	if updated.UncoveredSkills, err = s.uncoveredSkills(ctx, updated.RequiredSkills, updated.Assigned); err != nil {
+		return model.PullRequest{}, "", nil, err
 	}
+	updated.MissingSeniors = missing
 
-	if err := s.repo.UpdatePR(ctx, pr); err != nil {
-		return model.PullRequest{}, "", err
+	return updated, swap.NewUserID, ex, nil
+}
+
+// SubmitReview records an assigned reviewer's decision on an OPEN PR and returns the PR with updated reviewer states.
+func (s *Service) SubmitReview(ctx context.Context, review model.Review, ifVersion int64) (model.PullRequest, error) {
+	switch review.Decision {
+	case model.ReviewApproved, model.ReviewChangesRequested, model.ReviewCommented:
+	default:
+		return model.PullRequest{}, apiErrors.APIError{
+			Code:    apiErrors.InvalidArgument,
+			Message: "decision must be one of APPROVED, CHANGES_REQUESTED, COMMENTED",
+		}
+	}
+
+	pr, err := s.repo.AddReview(ctx, review, ifVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.NotFound, Message: "PR not found"}
+		case errors.Is(err, model.ErrPRMerged):
+			return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.PRAlreadyMerged, Message: "cannot review merged PR"}
+		case errors.Is(err, model.ErrPRNotOpen):
+			pr, getErr := s.repo.GetPR(ctx, review.PullRequestID)
+			if getErr != nil {
+				return model.PullRequest{}, getErr
+			}
+			if err := checkTransition(ActionReview, pr.Status); err != nil {
+				return model.PullRequest{}, err
+			}
+			return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.Conflict, Message: "PR was modified concurrently, retry the request"}
+		case errors.Is(err, model.ErrNotAssigned):
+			return model.PullRequest{}, apiErrors.APIError{Code: apiErrors.NotAssigned, Message: "user is not assigned to review this PR"}
+		case errors.Is(err, model.ErrConflict):
+			return model.PullRequest{}, preconditionFailed(ifVersion)
+		default:
+			return model.PullRequest{}, err
+		}
 	}
+	return pr, nil
+}
 
-	return pr, newReviewer, nil
+func pendingReviewers(userIDs []string) []model.ReviewerState {
+	out := make([]model.ReviewerState, 0, len(userIDs))
+	for _, id := range userIDs {
+		out = append(out, model.ReviewerState{UserID: id, State: model.ReviewPending})
+	}
+	return out
 }
 
-func (s *Service) GetPRsForReviewer(ctx context.Context, userID string) ([]model.PullRequestShort, error) {
-	return s.repo.GetAssignedPRsForUser(ctx, userID)
+// checkVersion verifies a client supplied PR version (the If-Match revision). Every method taking
+// an ifVersion goes through it: zero means the client set no precondition, any other value must
+// equal the PR's current version or the call fails with PRECONDITION_FAILED. The same error is
+// returned when the PR changes between this check and the write.
+func checkVersion(pr model.PullRequest, ifVersion int64) error {
+	if ifVersion != 0 && pr.Version != ifVersion {
+		return preconditionFailed(ifVersion)
+	}
+	return nil
+}
+
+func preconditionFailed(ifVersion int64) error {
+	return apiErrors.APIError{
+		Code:    apiErrors.PreconditionFailed,
+		Message: fmt.Sprintf("PR is no longer at version %d", ifVersion),
+	}
+}
+
+// reviewerChangeError maps store errors of reviewer updates made under a PR lock to API errors.
+func reviewerChangeError(err error) error {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		return apiErrors.APIError{Code: apiErrors.NotFound, Message: "PR not found"}
+	case errors.Is(err, model.ErrPRMerged):
+		return apiErrors.APIError{Code: apiErrors.PRAlreadyMerged, Message: "cannot reassign on merged PR"}
+	case errors.Is(err, model.ErrNotAssigned):
+		return apiErrors.APIError{Code: apiErrors.NotAssigned, Message: "reviewer is not assigned to this PR"}
+	case errors.Is(err, model.ErrAlreadyAssigned):
+		return apiErrors.APIError{Code: apiErrors.AlreadyAssigned, Message: "user is already assigned to this PR"}
+	case errors.Is(err, model.ErrNotEnoughReviewers):
+		return apiErrors.APIError{Code: apiErrors.NotEnoughReviewers, Message: "removing the reviewer would leave fewer reviewers than the team requires"}
+	case errors.Is(err, model.ErrConflict), errors.Is(err, model.ErrPRNotOpen):
+		return apiErrors.APIError{Code: apiErrors.Conflict, Message: "PR was modified concurrently, retry the request"}
+	default:
+		return err
+	}
+}
+
+// pickFromFallback fills up to missing reviewer slots from the fallback teams in order,
+// never picking the author, anyone listed in exclude or members at capacity. Picks are made with base completed
+// by the candidates and count of each team, skills and seniors covered by earlier teams are no longer
+// required from later ones. Pools are recorded in ex if it is set.
+func (s *Service) pickFromFallback(ctx context.Context, strategy AssignmentStrategy, base AssignmentRequest,
+	teams []string, exclude []string, missing int, ex *Explanation) ([]string, error) {
+	pr := base.PR
+	var picked []string
+	for i, team := range teams {
+		if missing <= 0 {
+			break
+		}
+		candidates, err := s.repo.GetActiveTeamMembersExcept(ctx, team, pr.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		taken := append(append([]string(nil), exclude...), picked...)
+		excluded := exclusions(pr, taken)
+		if candidates, _, err = s.withoutFull(ctx, without(candidates, taken), excluded); err != nil {
+			return nil, err
+		}
+		if len(candidates) == 0 && ex == nil {
+			continue
+		}
+		req := base.stage("fallback", i)
+		req.Candidates, req.Count = candidates, missing
+		if len(base.RequiredSkills) > 0 {
+			if req.RequiredSkills, err = s.uncoveredSkills(ctx, base.RequiredSkills, picked); err != nil {
+				return nil, err
+			}
+		}
+		if req.MinSeniors, err = s.seniorsMissing(ctx, base.MinSeniors, picked); err != nil {
+			return nil, err
+		}
+		got, err := s.pick(ctx, strategy, req, ex, team, true, excluded)
+		if err != nil {
+			return nil, err
+		}
+		picked = append(picked, got...)
+		missing -= len(got)
+	}
+	return picked, nil
+}
+
+// without returns items that are not present in exclude.
+func without(items, exclude []string) []string {
+	var out []string
+	for _, it := range items {
+		skip := false
+		for _, e := range exclude {
+			if it == e {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			out = append(out, it)
+		}
+	}
+	return out
 }
 
-func chooseUpToN(r *rand.Rand, items []string, n int) []string {
-	if len(items) <= n {
-		out := append([]string(nil), items...)
-		r.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
-		return out
+// GetPRHistory returns the event log of a PR, oldest event first.
+func (s *Service) GetPRHistory(ctx context.Context, prID string) ([]model.PREvent, error) {
+	if _, err := s.repo.GetPR(ctx, prID); err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, apiErrors.APIError{Code: apiErrors.NotFound, Message: "PR not found"}
+		}
+		return nil, err
 	}
-	out := append([]string(nil), items...)
-	r.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
-	return out[:n]
+	return s.repo.ListPREvents(ctx, prID)
+}
+
+func (s *Service) GetPRsForReviewer(ctx context.Context, userID string) ([]model.PullRequestShort, error) {
+	return s.repo.GetAssignedPRsForUser(ctx, userID)
 }
 
 func (s *Service) GetStats(ctx context.Context) (Stats, error) {
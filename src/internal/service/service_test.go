@@ -2,10 +2,10 @@ package service
 
 import (
 	"context"
-	"math/rand"
 	"testing"
 	"time"
 
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
 	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -26,6 +26,16 @@ func (m *MockRepositories) GetTeam(ctx context.Context, teamName string) (model.
 	return args.Get(0).(model.Team), args.Error(1)
 }
 
+func (m *MockRepositories) GetTeamSettings(ctx context.Context, teamName string) (model.TeamSettings, error) {
+	args := m.Called(ctx, teamName)
+	return args.Get(0).(model.TeamSettings), args.Error(1)
+}
+
+func (m *MockRepositories) UpsertTeamSettings(ctx context.Context, settings model.TeamSettings) (model.TeamSettings, error) {
+	args := m.Called(ctx, settings)
+	return args.Get(0).(model.TeamSettings), args.Error(1)
+}
+
 func (m *MockRepositories) SetUserIsActive(ctx context.Context, userID string, isActive bool) (model.User, error) {
 	args := m.Called(ctx, userID, isActive)
 	return args.Get(0).(model.User), args.Error(1)
@@ -41,6 +51,31 @@ func (m *MockRepositories) GetActiveTeamMembersExcept(ctx context.Context, teamN
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *MockRepositories) CreateAbsence(ctx context.Context, a model.Absence) (model.Absence, error) {
+	args := m.Called(ctx, a)
+	return args.Get(0).(model.Absence), args.Error(1)
+}
+
+func (m *MockRepositories) GetAbsence(ctx context.Context, absenceID int64) (model.Absence, error) {
+	args := m.Called(ctx, absenceID)
+	return args.Get(0).(model.Absence), args.Error(1)
+}
+
+func (m *MockRepositories) ListAbsences(ctx context.Context, userID string) ([]model.Absence, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]model.Absence), args.Error(1)
+}
+
+func (m *MockRepositories) UpdateAbsence(ctx context.Context, a model.Absence) (model.Absence, error) {
+	args := m.Called(ctx, a)
+	return args.Get(0).(model.Absence), args.Error(1)
+}
+
+func (m *MockRepositories) DeleteAbsence(ctx context.Context, absenceID int64) error {
+	args := m.Called(ctx, absenceID)
+	return args.Error(0)
+}
+
 func (m *MockRepositories) CreatePRWithReviewers(ctx context.Context, pr model.PullRequest) error {
 	args := m.Called(ctx, pr)
 	return args.Error(0)
@@ -51,11 +86,56 @@ func (m *MockRepositories) GetPR(ctx context.Context, prID string) (model.PullRe
 	return args.Get(0).(model.PullRequest), args.Error(1)
 }
 
+func (m *MockRepositories) GetOpenPRsForReviewers(ctx context.Context, userIDs []string) ([]model.PullRequest, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).([]model.PullRequest), args.Error(1)
+}
+
+func (m *MockRepositories) DeactivateUsersAndReassign(ctx context.Context, teamName string, userIDs []string, swaps []model.ReviewerSwap) error {
+	args := m.Called(ctx, teamName, userIDs, swaps)
+	return args.Error(0)
+}
+
 func (m *MockRepositories) UpdatePR(ctx context.Context, pr model.PullRequest) error {
 	args := m.Called(ctx, pr)
 	return args.Error(0)
 }
 
+// ReplaceReviewer returns either a fixed PR or, when the expectation returns a
+// func(model.ReviewerSwap) model.PullRequest, the PR it builds.
+func (m *MockRepositories) ReplaceReviewer(ctx context.Context, swap model.ReviewerSwap, version int64) (model.PullRequest, error) {
+	args := m.Called(ctx, swap, version)
+	if fn, ok := args.Get(0).(func(model.ReviewerSwap) model.PullRequest); ok {
+		return fn(swap), args.Error(1)
+	}
+	return args.Get(0).(model.PullRequest), args.Error(1)
+}
+
+func (m *MockRepositories) AssignReviewer(ctx context.Context, c model.ReviewerChange) (model.PullRequest, error) {
+	args := m.Called(ctx, c)
+	return args.Get(0).(model.PullRequest), args.Error(1)
+}
+
+func (m *MockRepositories) UnassignReviewer(ctx context.Context, c model.ReviewerChange) (model.PullRequest, error) {
+	args := m.Called(ctx, c)
+	return args.Get(0).(model.PullRequest), args.Error(1)
+}
+
+func (m *MockRepositories) TransitionPR(ctx context.Context, t model.PRTransition) (model.PullRequest, error) {
+	args := m.Called(ctx, t)
+	return args.Get(0).(model.PullRequest), args.Error(1)
+}
+
+func (m *MockRepositories) AddReview(ctx context.Context, review model.Review, ifVersion int64) (model.PullRequest, error) {
+	args := m.Called(ctx, review, ifVersion)
+	return args.Get(0).(model.PullRequest), args.Error(1)
+}
+
+func (m *MockRepositories) ListPREvents(ctx context.Context, prID string) ([]model.PREvent, error) {
+	args := m.Called(ctx, prID)
+	return args.Get(0).([]model.PREvent), args.Error(1)
+}
+
 func (m *MockRepositories) GetAssignedPRsForUser(ctx context.Context, userID string) ([]model.PullRequestShort, error) {
 	args := m.Called(ctx, userID)
 	return args.Get(0).([]model.PullRequestShort), args.Error(1)
@@ -71,38 +151,126 @@ func (m *MockRepositories) GetPRReviewStats(ctx context.Context) (map[string]int
 	return args.Get(0).(map[string]int), args.Error(1)
 }
 
-type MockRandSource struct {
+func (m *MockRepositories) GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockRepositories) ListCodeOwnerRules(ctx context.Context) ([]model.CodeOwnerRule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.CodeOwnerRule), args.Error(1)
+}
+
+func (m *MockRepositories) ReplaceCodeOwnerRules(ctx context.Context, rules []model.CodeOwnerRule) error {
+	args := m.Called(ctx, rules)
+	return args.Error(0)
+}
+
+func (m *MockRepositories) GetUserSkills(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).(map[string][]string), args.Error(1)
+}
+
+func (m *MockRepositories) SetUserSkills(ctx context.Context, userID string, skills []string) error {
+	args := m.Called(ctx, userID, skills)
+	return args.Error(0)
+}
+
+func (m *MockRepositories) SetUserMaxOpenReviews(ctx context.Context, userID string, maxOpen *int) (model.User, error) {
+	args := m.Called(ctx, userID, maxOpen)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockRepositories) GetUsersAtCapacity(ctx context.Context, userIDs []string) ([]string, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepositories) GetMaxOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockRepositories) GetRecentPairCounts(ctx context.Context, authorID, excludePRID string, window int) (map[string]int, error) {
+	args := m.Called(ctx, authorID, excludePRID, window)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockRepositories) GetTeamPairCounts(ctx context.Context, teamName string) ([]model.PairCount, error) {
+	args := m.Called(ctx, teamName)
+	return args.Get(0).([]model.PairCount), args.Error(1)
+}
+
+func (m *MockRepositories) SetUserRole(ctx context.Context, teamName, userID, role string) (model.User, error) {
+	args := m.Called(ctx, teamName, userID, role)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockRepositories) GetUserRoles(ctx context.Context, userIDs []string) (map[string]string, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
+type MockSeedSource struct {
 	values []int64
 	index  int
 }
 
-func NewMockRandSource(values ...int64) *MockRandSource {
-	return &MockRandSource{values: values}
+func NewMockSeedSource(values ...int64) *MockSeedSource {
+	return &MockSeedSource{values: values}
 }
 
-func (m *MockRandSource) Int63() int64 {
+func (m *MockSeedSource) Derive(_ string, dryRun bool) int64 {
 	if m.index >= len(m.values) {
 		m.index = 0
 	}
 	val := m.values[m.index]
-	m.index++
+	if !dryRun {
+		m.index++
+	}
 	return val
 }
 
-func (m *MockRandSource) Seed(int64) {}
+// swapReviewer emulates the store swapping reviewers on a copy of pr.
+func swapReviewer(pr model.PullRequest) func(model.ReviewerSwap) model.PullRequest {
+	return func(swap model.ReviewerSwap) model.PullRequest {
+		out := pr
+		out.Assigned = nil
+		for _, u := range pr.Assigned {
+			if u != swap.OldUserID {
+				out.Assigned = append(out.Assigned, u)
+			}
+		}
+		out.Assigned = append(out.Assigned, swap.NewUserID)
+		out.Version++
+		return out
+	}
+}
+
+// swapOf matches a reviewer swap on prID from oldUserID to a new reviewer accepted by isNew.
+func swapOf(prID, oldUserID string, isNew func(string) bool, isFallback bool) interface{} {
+	return mock.MatchedBy(func(swap model.ReviewerSwap) bool {
+		return swap.PullRequestID == prID && swap.OldUserID == oldUserID && isNew(swap.NewUserID) && swap.IsFallback == isFallback
+	})
+}
+
+func anyUser(string) bool { return true }
+
+func userIs(id string) func(string) bool { return func(u string) bool { return u == id } }
 
 func createTestService() (*Service, *MockRepositories) {
 	logger := zap.NewNop()
 	mockRepo := new(MockRepositories)
 
-	mockSource := NewMockRandSource(0, 1, 0) // предсказуемые значения
-	mockRand := rand.New(mockSource)
-
 	service := &Service{
-		repo: mockRepo,
-		log:  logger,
-		rnd:  mockRand,
+		repo:     mockRepo,
+		log:      logger,
+		strategy: NewRandomStrategy(),
+		seeds:    NewMockSeedSource(0, 1, 0), // предсказуемые значения
 	}
+	// по умолчанию ни у кого нет лимита ревью
+	mockRepo.On("GetUsersAtCapacity", mock.Anything, mock.Anything).Return([]string(nil), nil).Maybe()
+	mockRepo.On("GetMaxOpenReviews", mock.Anything, mock.Anything).Return(map[string]int{}, nil).Maybe()
 
 	return service, mockRepo
 }
@@ -113,8 +281,8 @@ func TestCreateTeam_Success(t *testing.T) {
 	team := model.Team{
 		TeamName: "backend",
 		Members: []model.TeamMember{
-			{UserID: "u1", Username: "Alice", IsActive: true},
-			{UserID: "u2", Username: "Bob", IsActive: true},
+			{UserID: "u1", Username: "Alice", IsActive: true, Role: model.RoleSenior},
+			{UserID: "u2", Username: "Bob", IsActive: true, Role: model.RoleMember},
 		},
 	}
 
@@ -171,13 +339,13 @@ func TestCreateTeam_UserAlreadyExists(t *testing.T) {
 func TestCreatePR_Success(t *testing.T) {
 	logger := zap.NewNop()
 	mockRepo := new(MockRepositories)
-	mockRand := rand.New(rand.NewSource(1))
-
 	service := &Service{
-		repo: mockRepo,
-		log:  logger,
-		rnd:  mockRand,
+		repo:     mockRepo,
+		log:      logger,
+		strategy: NewRandomStrategy(),
+		seeds:    NewSeedSource(1),
 	}
+	mockRepo.On("GetUsersAtCapacity", mock.Anything, mock.Anything).Return([]string(nil), nil).Maybe()
 
 	author := model.User{
 		UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true,
@@ -185,6 +353,7 @@ func TestCreatePR_Success(t *testing.T) {
 
 	mockRepo.On("GetUser", mock.Anything, "u1").Return(author, nil)
 	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
 	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3", "u4"}, nil)
 	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
 		return pr.PullRequestID == "pr1" &&
@@ -211,6 +380,90 @@ func TestCreatePR_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreatePR_LeastLoaded(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.strategy = NewLeastLoadedStrategy(mockRepo)
+
+	author := model.User{UserID: "u1", TeamName: "backend", IsActive: true}
+	loads := map[string]int{"u2": 8, "u3": 0, "u4": 1}
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(author, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3", "u4"}, nil)
+	mockRepo.On("GetOpenReviewCounts", mock.Anything, []string{"u2", "u3", "u4"}).Return(loads, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	result, err := service.CreatePR(context.Background(), "pr1", "Loaded PR", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3", "u4"}, result.Assigned)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreatePR_HonorsTeamSettings(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.strategies = map[string]AssignmentStrategy{StrategyRoundRobin: NewRoundRobinStrategy()}
+
+	author := model.User{UserID: "u1", TeamName: "platform", IsActive: true}
+	settings := model.TeamSettings{TeamName: "platform", ReviewersCount: 3, MinReviewers: 1, Strategy: StrategyRoundRobin}
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(author, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "platform").Return(settings, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "platform", "u1").Return([]string{"u5", "u4", "u3", "u2"}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	result, err := service.CreatePR(context.Background(), "pr1", "Platform PR", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u3", "u4"}, result.Assigned)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreatePR_BelowMinReviewers(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	author := model.User{UserID: "u1", TeamName: "docs", IsActive: true}
+	settings := model.TeamSettings{TeamName: "docs", ReviewersCount: 2, MinReviewers: 2}
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(author, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "docs").Return(settings, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "docs", "u1").Return([]string{"u2"}, nil)
+
+	_, err := service.CreatePR(context.Background(), "pr1", "Docs PR", "u1")
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotEnoughReviewers, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "CreatePRWithReviewers")
+}
+
+func TestCreatePR_FillsFromFallbackTeams(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	author := model.User{UserID: "u1", TeamName: "docs", IsActive: true}
+	settings := model.TeamSettings{TeamName: "docs", ReviewersCount: 2, FallbackTeams: []string{"empty", "backend"}}
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(author, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "docs").Return(settings, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "docs", "u1").Return([]string{"u2"}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "empty", "u1").Return([]string{}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u7"}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
+		return len(pr.Assigned) == 2 && len(pr.Fallback) == 1 && pr.Fallback[0] == "u7"
+	})).Return(nil)
+
+	result, err := service.CreatePR(context.Background(), "pr1", "Docs PR", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u7"}, result.Assigned)
+	assert.Equal(t, []string{"u7"}, result.Fallback)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestCreatePR_AuthorNotFound(t *testing.T) {
 	service, mockRepo := createTestService()
 
@@ -229,6 +482,7 @@ func TestCreatePR_NoReviewersAvailable(t *testing.T) {
 
 	mockRepo.On("GetUser", mock.Anything, "u1").Return(author, nil)
 	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "solo").Return(model.TeamSettings{}, model.ErrNotFound)
 	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "solo", "u1").Return([]string{}, nil)
 	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
 		return len(pr.Assigned) == 0
@@ -247,6 +501,7 @@ func TestCreatePR_OnlyOneReviewerAvailable(t *testing.T) {
 
 	mockRepo.On("GetUser", mock.Anything, "u1").Return(author, nil)
 	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "small").Return(model.TeamSettings{}, model.ErrNotFound)
 	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "small", "u1").Return([]string{"u2"}, nil)
 	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
 		return len(pr.Assigned) == 1 && pr.Assigned[0] == "u2"
@@ -264,17 +519,20 @@ func TestMergePR_Success(t *testing.T) {
 
 	openPR := model.PullRequest{
 		PullRequestID: "pr1",
+		AuthorID:      "u1",
 		Status:        "OPEN",
 		Assigned:      []string{"u2"},
 		CreatedAt:     time.Now().UTC(),
 	}
 
 	mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
 	mockRepo.On("UpdatePR", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
 		return pr.Status == "MERGED" && pr.MergedAt != nil
 	})).Return(nil)
 
-	result, err := service.MergePR(context.Background(), "pr1")
+	result, err := service.MergePR(context.Background(), "pr1", 0, false)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "MERGED", result.Status)
@@ -293,13 +551,143 @@ func TestMergePR_Idempotent(t *testing.T) {
 
 	mockRepo.On("GetPR", mock.Anything, "pr1").Return(mergedPR, nil)
 
-	result, err := service.MergePR(context.Background(), "pr1")
+	result, err := service.MergePR(context.Background(), "pr1", 0, false)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "MERGED", result.Status)
 	mockRepo.AssertNotCalled(t, "UpdatePR")
 }
 
+func TestMergePR_ConcurrentMerge(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mergedAt := time.Now().UTC()
+	openPR := model.PullRequest{PullRequestID: "pr1", AuthorID: "u1", Status: "OPEN", Version: 2}
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mergedPR := model.PullRequest{PullRequestID: "pr1", Status: "MERGED", MergedAt: &mergedAt, Version: 3}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR, nil).Once()
+	mockRepo.On("UpdatePR", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
+		return pr.Version == 2
+	})).Return(model.ErrConflict)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(mergedPR, nil).Once()
+
+	result, err := service.MergePR(context.Background(), "pr1", 0, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, mergedPR, result)
+}
+
+func TestMergePR_Conflict(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	openPR := model.PullRequest{PullRequestID: "pr1", AuthorID: "u1", Status: "OPEN", Version: 2}
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(openPR, nil).Once()
+	mockRepo.On("UpdatePR", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(model.ErrConflict)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{PullRequestID: "pr1", Status: "OPEN", Version: 3}, nil).Once()
+
+	_, err := service.MergePR(context.Background(), "pr1", 0, false)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.Conflict, apiErr.Code)
+}
+
+func TestMergePR_GateBlocks(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.requiredApprovals = 1
+
+	pr := model.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "u1",
+		Status:        "OPEN",
+		Assigned:      []string{"u2", "u3", "u4"},
+		Reviewers: []model.ReviewerState{
+			{UserID: "u2", State: model.ReviewApproved},
+			{UserID: "u3", State: model.ReviewChangesRequested},
+			{UserID: "u4", State: model.ReviewPending},
+		},
+	}
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").
+		Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 3, RequiredApprovals: 2}, nil)
+
+	_, err := service.MergePR(context.Background(), "pr1", 0, false)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.MergeBlocked, apiErr.Code)
+	assert.Equal(t, MergeGateDetails{
+		RequiredApprovals:  2,
+		Approvals:          1,
+		MissingApprovals:   1,
+		AwaitingApproval:   []string{"u3", "u4"},
+		ChangesRequestedBy: []string{"u3"},
+	}, apiErr.Details)
+	mockRepo.AssertNotCalled(t, "UpdatePR", mock.Anything, mock.Anything)
+}
+
+func TestMergePR_GatePasses(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.requiredApprovals = 2
+
+	pr := model.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "u1",
+		Status:        "OPEN",
+		Assigned:      []string{"u2", "u3"},
+		Reviewers: []model.ReviewerState{
+			{UserID: "u2", State: model.ReviewApproved},
+			{UserID: "u3", State: model.ReviewApproved},
+		},
+	}
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("UpdatePR", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
+		return pr.Status == "MERGED" && !pr.ForceMerged
+	})).Return(nil)
+
+	result, err := service.MergePR(context.Background(), "pr1", 0, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "MERGED", result.Status)
+}
+
+func TestMergePR_Force(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.requiredApprovals = 2
+
+	pr := model.PullRequest{PullRequestID: "pr1", AuthorID: "u1", Status: "OPEN", Assigned: []string{"u2"}}
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("UpdatePR", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
+		return pr.Status == "MERGED" && pr.ForceMerged
+	})).Return(nil)
+
+	result, err := service.MergePR(context.Background(), "pr1", 0, true)
+
+	assert.NoError(t, err)
+	assert.True(t, result.ForceMerged)
+	mockRepo.AssertNotCalled(t, "GetUser", mock.Anything, mock.Anything)
+}
+
+func TestMergePR_IfVersionMismatch(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{PullRequestID: "pr1", Status: "OPEN", Version: 4}, nil)
+
+	_, err := service.MergePR(context.Background(), "pr1", 3, false)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.PreconditionFailed, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "UpdatePR", mock.Anything, mock.Anything)
+}
+
 func TestReassignReviewer_Success(t *testing.T) {
 	service, mockRepo := createTestService()
 
@@ -313,10 +701,12 @@ func TestReassignReviewer_Success(t *testing.T) {
 
 	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
 	mockRepo.On("GetUser", mock.Anything, "u2").Return(oldUser, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
 	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u2").Return([]string{"u4", "u5"}, nil)
-	mockRepo.On("UpdatePR", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", anyUser, false), int64(0)).Return(swapReviewer(pr), nil)
 
-	result, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u2")
+	result, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
 
 	assert.NoError(t, err)
 	assert.Contains(t, []string{"u4", "u5"}, newReviewer)
@@ -324,6 +714,157 @@ func TestReassignReviewer_Success(t *testing.T) {
 	assert.Contains(t, result.Assigned, newReviewer)
 }
 
+func TestReassignReviewer_ExplicitReplacement(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	pr := model.PullRequest{
+		PullRequestID: "pr1",
+		Status:        "OPEN",
+		Assigned:      []string{"u2", "u3"},
+		AuthorID:      "u1",
+	}
+	oldUser := model.User{UserID: "u2", TeamName: "backend", IsActive: true}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(oldUser, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u5").Return(model.User{UserID: "u5", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3", "u4", "u5"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("ReplaceReviewer", mock.Anything, mock.MatchedBy(func(swap model.ReviewerSwap) bool {
+		return swap.NewUserID == "u5" && swap.Assignment.Strategy == model.AssignedManually
+	}), int64(0)).Return(swapReviewer(pr), nil)
+
+	_, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "u5", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u5", newReviewer)
+}
+
+func TestReassignReviewer_ExplicitReplacementFromAuthorTeam(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	pr := model.PullRequest{PullRequestID: "pr1", Status: "OPEN", Assigned: []string{"u2"}, Fallback: []string{"u2"}, AuthorID: "u1"}
+	settings := model.TeamSettings{TeamName: "small", FallbackTeams: []string{"backend"}}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(model.User{UserID: "u2", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "small", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u5").Return(model.User{UserID: "u5", TeamName: "small", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "small").Return(settings, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "small", "u1").Return([]string{"u5"}, nil)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", userIs("u5"), false), int64(0)).Return(swapReviewer(pr), nil)
+
+	_, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "u5", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u5", newReviewer)
+}
+
+func TestReassignReviewer_ExplicitReplacementRejected(t *testing.T) {
+	cases := []struct {
+		name    string
+		newUser string
+		user    model.User
+		active  []string
+		code    apiErrors.ErrorCode
+	}{
+		{name: "author", newUser: "u1", code: apiErrors.ReviewerIsAuthor},
+		{name: "already assigned", newUser: "u3", code: apiErrors.AlreadyAssigned},
+		{name: "other team", newUser: "u7", user: model.User{UserID: "u7", TeamName: "mobile", IsActive: true}, code: apiErrors.ReviewerNotInTeam},
+		{name: "inactive", newUser: "u8", user: model.User{UserID: "u8", TeamName: "backend"}, code: apiErrors.ReviewerInactive},
+		{name: "absent", newUser: "u9", user: model.User{UserID: "u9", TeamName: "backend", IsActive: true}, active: []string{"u2", "u3"}, code: apiErrors.ReviewerAbsent},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service, mockRepo := createTestService()
+
+			pr := model.PullRequest{PullRequestID: "pr1", Status: "OPEN", Assigned: []string{"u2", "u3"}, AuthorID: "u1"}
+			mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+			mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+			mockRepo.On("GetUser", mock.Anything, "u2").Return(model.User{UserID: "u2", TeamName: "backend", IsActive: true}, nil)
+			mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+			mockRepo.On("GetUser", mock.Anything, c.newUser).Return(c.user, nil)
+			mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+			mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return(c.active, nil)
+
+			_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u2", c.newUser, 0)
+
+			var apiErr apiErrors.APIError
+			if assert.ErrorAs(t, err, &apiErr) {
+				assert.Equal(t, c.code, apiErr.Code)
+			}
+			mockRepo.AssertNotCalled(t, "ReplaceReviewer", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestReassignReviewer_LeastLoaded(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.strategy = NewLeastLoadedStrategy(mockRepo)
+
+	pr := model.PullRequest{
+		PullRequestID: "pr1",
+		Status:        "OPEN",
+		Assigned:      []string{"u2", "u3"},
+		AuthorID:      "u1",
+	}
+	oldUser := model.User{UserID: "u2", TeamName: "backend", IsActive: true}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(oldUser, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u2").Return([]string{"u4", "u5"}, nil)
+	mockRepo.On("GetOpenReviewCounts", mock.Anything, []string{"u4", "u5"}).Return(map[string]int{"u4": 5}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", userIs("u5"), false), int64(0)).Return(swapReviewer(pr), nil)
+
+	_, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u5", newReviewer)
+}
+
+func TestReassignReviewer_MergedConcurrently(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	pr := model.PullRequest{PullRequestID: "pr1", Status: "OPEN", Assigned: []string{"u2"}, AuthorID: "u1"}
+	oldUser := model.User{UserID: "u2", TeamName: "backend", IsActive: true}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(oldUser, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u2").Return([]string{"u3"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", userIs("u3"), false), int64(0)).Return(model.PullRequest{}, model.ErrPRMerged)
+
+	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.PRAlreadyMerged, apiErr.Code)
+}
+
+func TestReassignReviewer_StaleVersion(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	pr := model.PullRequest{PullRequestID: "pr1", Status: "OPEN", Assigned: []string{"u2"}, AuthorID: "u1", Version: 7}
+	oldUser := model.User{UserID: "u2", TeamName: "backend", IsActive: true}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(oldUser, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u2").Return([]string{"u3"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", userIs("u3"), false), int64(7)).Return(model.PullRequest{}, model.ErrConflict)
+
+	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.Conflict, apiErr.Code)
+}
+
 func TestReassignReviewer_MergedPR(t *testing.T) {
 	service, mockRepo := createTestService()
 
@@ -331,7 +872,7 @@ func TestReassignReviewer_MergedPR(t *testing.T) {
 
 	mockRepo.On("GetPR", mock.Anything, "pr1").Return(mergedPR, nil)
 
-	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u2")
+	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
 
 	assert.Error(t, err)
 }
@@ -343,7 +884,7 @@ func TestReassignReviewer_NotAssigned(t *testing.T) {
 
 	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
 
-	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u2")
+	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
 
 	assert.Error(t, err)
 }
@@ -361,13 +902,43 @@ func TestReassignReviewer_NoCandidates(t *testing.T) {
 
 	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
 	mockRepo.On("GetUser", mock.Anything, "u2").Return(oldUser, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "small", IsActive: true}, nil)
 	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "small", "u2").Return([]string{}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "small").Return(model.TeamSettings{}, model.ErrNotFound)
 
-	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u2")
+	_, _, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
 
 	assert.Error(t, err)
 }
 
+func TestReassignReviewer_FallbackTeam(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	pr := model.PullRequest{
+		PullRequestID: "pr1",
+		Status:        "OPEN",
+		Assigned:      []string{"u2"},
+		AuthorID:      "u1",
+	}
+	oldUser := model.User{UserID: "u2", TeamName: "small", IsActive: true}
+	settings := model.TeamSettings{TeamName: "small", ReviewersCount: 1, FallbackTeams: []string{"backend"}}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(oldUser, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "small", IsActive: true}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "small", "u2").Return([]string{"u1"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "small").Return(settings, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u9"}, nil)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", userIs("u9"), true), int64(0)).
+		Return(model.PullRequest{PullRequestID: "pr1", Status: "OPEN", AuthorID: "u1", Assigned: []string{"u9"}, Fallback: []string{"u9"}}, nil)
+
+	result, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u9", newReviewer)
+	assert.Equal(t, []string{"u9"}, result.Fallback)
+}
+
 func TestReassignReviewer_ExcludeAuthorFromCandidates(t *testing.T) {
 	service, mockRepo := createTestService()
 
@@ -381,23 +952,179 @@ func TestReassignReviewer_ExcludeAuthorFromCandidates(t *testing.T) {
 
 	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
 	mockRepo.On("GetUser", mock.Anything, "u2").Return(oldUser, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "team", IsActive: true}, nil)
 	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "team", "u2").Return([]string{"u1", "u3", "u4"}, nil)
-	mockRepo.On("UpdatePR", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
-		for _, reviewer := range pr.Assigned {
-			if reviewer == "u1" {
-				return false
-			}
-		}
-		return true
-	})).Return(nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "team").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", func(newUserID string) bool {
+		return newUserID != "u1"
+	}, false), int64(0)).Return(swapReviewer(pr), nil)
 
-	_, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u2")
+	_, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
 
 	assert.NoError(t, err)
 	assert.NotEqual(t, "u1", newReviewer) // Автор не должен быть назначен
 	assert.Contains(t, []string{"u3", "u4"}, newReviewer)
 }
 
+func TestReassignReviewer_FallbackReviewerStaysFallback(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	pr := model.PullRequest{
+		PullRequestID: "pr1",
+		Status:        "OPEN",
+		Assigned:      []string{"u2"},
+		Fallback:      []string{"u2"},
+		AuthorID:      "u1",
+	}
+	oldUser := model.User{UserID: "u2", TeamName: "backend", IsActive: true}
+	settings := model.TeamSettings{TeamName: "small", ReviewersCount: 1, FallbackTeams: []string{"backend"}}
+
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr, nil)
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(oldUser, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "small", IsActive: true}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u2").Return([]string{"u9"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "small").Return(settings, nil)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", userIs("u9"), true), int64(0)).
+		Return(model.PullRequest{PullRequestID: "pr1", Status: "OPEN", AuthorID: "u1", Assigned: []string{"u9"}, Fallback: []string{"u9"}}, nil)
+
+	result, newReviewer, err := service.ReassignReviewer(context.Background(), "pr1", "u2", "", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u9", newReviewer)
+	assert.Equal(t, []string{"u9"}, result.Fallback)
+	mockRepo.AssertNotCalled(t, "GetTeamSettings", mock.Anything, "backend")
+}
+
+func TestSubmitReview_Success(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	review := model.Review{PullRequestID: "pr1", UserID: "u2", Decision: model.ReviewApproved, Comment: "LGTM"}
+	reviewedAt := time.Now().UTC()
+	updated := model.PullRequest{
+		PullRequestID: "pr1",
+		Status:        "OPEN",
+		Assigned:      []string{"u2", "u3"},
+		Reviewers: []model.ReviewerState{
+			{UserID: "u2", State: model.ReviewApproved, Comment: "LGTM", ReviewedAt: &reviewedAt},
+			{UserID: "u3", State: model.ReviewPending},
+		},
+	}
+
+	mockRepo.On("AddReview", mock.Anything, review, int64(0)).Return(updated, nil)
+
+	result, err := service.SubmitReview(context.Background(), review, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, updated, result)
+}
+
+func TestSubmitReview_Errors(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	_, err := service.SubmitReview(context.Background(), model.Review{PullRequestID: "pr1", UserID: "u2", Decision: "LOOKS_FINE"}, 0)
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.InvalidArgument, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "AddReview", mock.Anything, mock.Anything, mock.Anything)
+
+	notAssigned := model.Review{PullRequestID: "pr1", UserID: "u9", Decision: model.ReviewCommented}
+	mockRepo.On("AddReview", mock.Anything, notAssigned, int64(0)).Return(model.PullRequest{}, model.ErrNotAssigned)
+	_, err = service.SubmitReview(context.Background(), notAssigned, 0)
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotAssigned, apiErr.Code)
+
+	merged := model.Review{PullRequestID: "pr2", UserID: "u2", Decision: model.ReviewApproved}
+	mockRepo.On("AddReview", mock.Anything, merged, int64(0)).Return(model.PullRequest{}, model.ErrPRMerged)
+	_, err = service.SubmitReview(context.Background(), merged, 0)
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.PRAlreadyMerged, apiErr.Code)
+}
+
+func TestGetTeamSettings_Defaults(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(model.Team{TeamName: "backend"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+
+	result, err := service.GetTeamSettings(context.Background(), "backend")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.TeamSettings{TeamName: "backend", ReviewersCount: DefaultReviewersCount}, result)
+}
+
+func TestUpdateTeamSettings_Success(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.strategies = map[string]AssignmentStrategy{StrategyLeastLoaded: NewLeastLoadedStrategy(mockRepo)}
+
+	count, strategy := 3, StrategyLeastLoaded
+	expected := model.TeamSettings{TeamName: "platform", ReviewersCount: 3, MinReviewers: 1, Strategy: StrategyLeastLoaded}
+
+	mockRepo.On("GetTeam", mock.Anything, "platform").Return(model.Team{TeamName: "platform"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "platform").
+		Return(model.TeamSettings{TeamName: "platform", ReviewersCount: 2, MinReviewers: 1}, nil)
+	mockRepo.On("UpsertTeamSettings", mock.Anything, expected).Return(expected, nil)
+
+	result, err := service.UpdateTeamSettings(context.Background(), "platform", TeamSettingsUpdate{ReviewersCount: &count, Strategy: &strategy})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateTeamSettings_Invalid(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetTeam", mock.Anything, "docs").Return(model.Team{TeamName: "docs"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "docs").Return(model.TeamSettings{}, model.ErrNotFound)
+
+	minReviewers := 3
+	_, err := service.UpdateTeamSettings(context.Background(), "docs", TeamSettingsUpdate{MinReviewers: &minReviewers})
+	assert.Error(t, err)
+
+	unknown := "by_mood"
+	_, err = service.UpdateTeamSettings(context.Background(), "docs", TeamSettingsUpdate{Strategy: &unknown})
+	assert.Error(t, err)
+
+	window := -1
+	_, err = service.UpdateTeamSettings(context.Background(), "docs", TeamSettingsUpdate{AntiAffinityWindow: &window})
+	assert.Error(t, err)
+
+	seniors := 3
+	_, err = service.UpdateTeamSettings(context.Background(), "docs", TeamSettingsUpdate{MinSeniorReviewers: &seniors})
+	assert.Error(t, err)
+
+	policy := "ignore"
+	_, err = service.UpdateTeamSettings(context.Background(), "docs", TeamSettingsUpdate{SeniorPolicy: &policy})
+	assert.Error(t, err)
+
+	mockRepo.AssertNotCalled(t, "UpsertTeamSettings")
+}
+
+func TestUpdateTeamSettings_FallbackTeams(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetTeam", mock.Anything, "docs").Return(model.Team{TeamName: "docs"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "docs").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(model.Team{TeamName: "backend"}, nil)
+	mockRepo.On("GetTeam", mock.Anything, "ghost").Return(model.Team{}, model.ErrNotFound)
+
+	self := []string{"backend", "docs"}
+	_, err := service.UpdateTeamSettings(context.Background(), "docs", TeamSettingsUpdate{FallbackTeams: &self})
+	assert.Error(t, err)
+
+	missing := []string{"backend", "ghost"}
+	_, err = service.UpdateTeamSettings(context.Background(), "docs", TeamSettingsUpdate{FallbackTeams: &missing})
+	assert.Error(t, err)
+
+	valid := []string{"backend"}
+	expected := model.TeamSettings{TeamName: "docs", ReviewersCount: DefaultReviewersCount, FallbackTeams: valid}
+	mockRepo.On("UpsertTeamSettings", mock.Anything, expected).Return(expected, nil)
+
+	result, err := service.UpdateTeamSettings(context.Background(), "docs", TeamSettingsUpdate{FallbackTeams: &valid})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
 func TestGetPRsForReviewer(t *testing.T) {
 	service, mockRepo := createTestService()
 
@@ -439,3 +1166,255 @@ func TestSetUserIsActive_UserNotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, model.User{}, result)
 }
+
+func TestReassignOpenReviews(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	leaving := model.User{UserID: "u2", TeamName: "backend", IsActive: false}
+	assigned := []model.PullRequestShort{
+		{PullRequestID: "pr1", AuthorID: "u1", Status: "OPEN"},
+		{PullRequestID: "pr2", AuthorID: "u3", Status: "OPEN"},
+		{PullRequestID: "pr3", AuthorID: "u1", Status: "MERGED"},
+	}
+	pr1 := model.PullRequest{PullRequestID: "pr1", Status: "OPEN", AuthorID: "u1", Assigned: []string{"u2", "u3"}}
+	pr2 := model.PullRequest{PullRequestID: "pr2", Status: "OPEN", AuthorID: "u3", Assigned: []string{"u1", "u2", "u4"}}
+
+	mockRepo.On("GetUser", mock.Anything, "u2").Return(leaving, nil)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "u3").Return(model.User{UserID: "u3", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetAssignedPRsForUser", mock.Anything, "u2").Return(assigned, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(pr1, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr2").Return(pr2, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u2").Return([]string{"u1", "u3", "u4"}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("ReplaceReviewer", mock.Anything, swapOf("pr1", "u2", userIs("u4"), false), int64(0)).Return(swapReviewer(pr1), nil)
+
+	report, err := service.ReassignOpenReviews(context.Background(), "u2")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Reassignment{{PullRequestID: "pr1", OldReviewerID: "u2", NewReviewerID: "u4"}}, report.Reassigned)
+	assert.Len(t, report.Failed, 1)
+	assert.Equal(t, "pr2", report.Failed[0].PullRequestID)
+	assert.Equal(t, apiErrors.NoCandidate, report.Failed[0].Code)
+	mockRepo.AssertNotCalled(t, "GetPR", mock.Anything, "pr3")
+}
+
+func TestDeactivateTeamUsers(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.strategy = NewLeastLoadedStrategy(mockRepo)
+	service.seeds = NewMockSeedSource(7, 8, 9)
+
+	team := model.Team{TeamName: "backend", Members: []model.TeamMember{
+		{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}, {UserID: "u5"},
+	}}
+	prs := []model.PullRequest{
+		{PullRequestID: "pr1", AuthorID: "u1", Status: "OPEN", Assigned: []string{"u2", "u3"}, Version: 4},
+		{PullRequestID: "pr2", AuthorID: "u4", Status: "OPEN", Assigned: []string{"u2", "u5"}},
+		{PullRequestID: "pr3", AuthorID: "u5", Status: "OPEN", Assigned: []string{"u1", "u3", "u4"}},
+	}
+
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(team, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "").Return([]string{"u1", "u2", "u3", "u4", "u5"}, nil)
+	mockRepo.On("GetOpenReviewCounts", mock.Anything, []string{"u1", "u4", "u5"}).Return(map[string]int{"u1": 1, "u4": 3, "u5": 2}, nil).Once()
+	mockRepo.On("GetOpenPRsForReviewers", mock.Anything, []string{"u2", "u3"}).Return(prs, nil)
+	mockRepo.On("DeactivateUsersAndReassign", mock.Anything, "backend", []string{"u2", "u3"}, []model.ReviewerSwap{
+		{PullRequestID: "pr1", OldUserID: "u2", NewUserID: "u5", Version: 4, Assignment: model.AssignmentInfo{Strategy: StrategyLeastLoaded, Seed: 7}},
+		{PullRequestID: "pr1", OldUserID: "u3", NewUserID: "u4", Version: 4, Assignment: model.AssignmentInfo{Strategy: StrategyLeastLoaded, Seed: 8}},
+		{PullRequestID: "pr2", OldUserID: "u2", NewUserID: "u1", Assignment: model.AssignmentInfo{Strategy: StrategyLeastLoaded, Seed: 9}},
+	}).Return(nil)
+
+	result, err := service.DeactivateTeamUsers(context.Background(), "backend", []string{"u2", "u3", "u2"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u3"}, result.Deactivated)
+	assert.Len(t, result.Reassigned, 3)
+	assert.Equal(t, []FailedReassignment{{PullRequestID: "pr3", Code: apiErrors.NoCandidate, Message: "no active replacement candidate for u3"}}, result.Failed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeactivateTeamUsers_FallbackRelativeToAuthor(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.seeds = NewMockSeedSource(7, 8, 9)
+
+	team := model.Team{TeamName: "backend", Members: []model.TeamMember{{UserID: "u2"}, {UserID: "u3"}}}
+	settings := model.TeamSettings{TeamName: "backend", FallbackTeams: []string{"frontend"}}
+	prs := []model.PullRequest{
+		{PullRequestID: "pr1", AuthorID: "f1", Status: "OPEN", Assigned: []string{"u2"}},
+		{PullRequestID: "pr2", AuthorID: "u3", Status: "OPEN", Assigned: []string{"u2"}},
+		{PullRequestID: "pr3", AuthorID: "f2", Status: "OPEN", Assigned: []string{"u2", "u3"}},
+	}
+
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(team, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(settings, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "").Return([]string{"u2", "u3"}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "frontend", "").Return([]string{"f1"}, nil)
+	mockRepo.On("GetOpenReviewCounts", mock.Anything, mock.Anything).Return(map[string]int{}, nil)
+	mockRepo.On("GetOpenPRsForReviewers", mock.Anything, []string{"u2"}).Return(prs, nil)
+	mockRepo.On("GetUser", mock.Anything, "f1").Return(model.User{UserID: "f1", TeamName: "frontend", IsActive: true}, nil)
+	mockRepo.On("GetUser", mock.Anything, "f2").Return(model.User{UserID: "f2", TeamName: "frontend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "frontend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("DeactivateUsersAndReassign", mock.Anything, "backend", []string{"u2"}, []model.ReviewerSwap{
+		{PullRequestID: "pr1", OldUserID: "u2", NewUserID: "u3", IsFallback: true, Assignment: model.AssignmentInfo{Strategy: StrategyRandom, Seed: 7}},
+		{PullRequestID: "pr2", OldUserID: "u2", NewUserID: "f1", IsFallback: true, Assignment: model.AssignmentInfo{Strategy: StrategyRandom, Seed: 8}},
+		{PullRequestID: "pr3", OldUserID: "u2", NewUserID: "f1", IsFallback: false, Assignment: model.AssignmentInfo{Strategy: StrategyRandom, Seed: 9}},
+	}).Return(nil)
+
+	result, err := service.DeactivateTeamUsers(context.Background(), "backend", []string{"u2"})
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Reassigned, 3)
+	assert.Empty(t, result.Failed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeactivateTeamUsers_NotAMember(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(model.Team{TeamName: "backend", Members: []model.TeamMember{{UserID: "u1"}}}, nil)
+
+	_, err := service.DeactivateTeamUsers(context.Background(), "backend", []string{"u9"})
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotFound, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "DeactivateUsersAndReassign")
+}
+
+func TestDeactivateTeamUsers_Conflict(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(model.Team{TeamName: "backend", Members: []model.TeamMember{{UserID: "u1"}, {UserID: "u2"}}}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "").Return([]string{"u1", "u2"}, nil)
+	mockRepo.On("GetOpenReviewCounts", mock.Anything, []string{"u1"}).Return(map[string]int{}, nil)
+	mockRepo.On("GetOpenPRsForReviewers", mock.Anything, []string{"u2"}).Return([]model.PullRequest{}, nil)
+	mockRepo.On("DeactivateUsersAndReassign", mock.Anything, "backend", []string{"u2"}, []model.ReviewerSwap(nil)).Return(model.ErrConflict)
+
+	_, err := service.DeactivateTeamUsers(context.Background(), "backend", []string{"u2"})
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.Conflict, apiErr.Code)
+}
+
+func TestAddAbsence_Success(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	start := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	absence := model.Absence{UserID: "u1", StartsAt: start, EndsAt: start.Add(72 * time.Hour), Reason: "vacation"}
+	saved := absence
+	saved.AbsenceID = 7
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1"}, nil)
+	mockRepo.On("CreateAbsence", mock.Anything, absence).Return(saved, nil)
+
+	result, err := service.AddAbsence(context.Background(), absence)
+
+	assert.NoError(t, err)
+	assert.Equal(t, saved, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAddAbsence_InvalidPeriod(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	start := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1"}, nil)
+
+	_, err := service.AddAbsence(context.Background(), model.Absence{UserID: "u1", StartsAt: start, EndsAt: start})
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.InvalidArgument, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "CreateAbsence")
+}
+
+func TestUpdateAbsence_EndsEarly(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	start := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	existing := model.Absence{AbsenceID: 7, UserID: "u1", StartsAt: start, EndsAt: start.Add(72 * time.Hour), Reason: "vacation"}
+	newEnd := start.Add(24 * time.Hour)
+	expected := existing
+	expected.EndsAt = newEnd
+
+	mockRepo.On("GetAbsence", mock.Anything, int64(7)).Return(existing, nil)
+	mockRepo.On("UpdateAbsence", mock.Anything, expected).Return(expected, nil)
+
+	result, err := service.UpdateAbsence(context.Background(), 7, AbsenceUpdate{EndsAt: &newEnd})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeleteAbsence_NotFound(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("DeleteAbsence", mock.Anything, int64(42)).Return(model.ErrNotFound)
+
+	err := service.DeleteAbsence(context.Background(), 42)
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotFound, apiErr.Code)
+}
+
+func TestGetPRHistory(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	events := []model.PREvent{
+		{EventID: 1, PullRequestID: "pr1", Type: model.EventCreated},
+		{EventID: 2, PullRequestID: "pr1", Type: model.EventReviewersAssigned, Data: map[string]any{"strategy": StrategyRandom}},
+	}
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{PullRequestID: "pr1"}, nil)
+	mockRepo.On("ListPREvents", mock.Anything, "pr1").Return(events, nil)
+	mockRepo.On("GetPR", mock.Anything, "missing").Return(model.PullRequest{}, model.ErrNotFound)
+
+	result, err := service.GetPRHistory(context.Background(), "pr1")
+	assert.NoError(t, err)
+	assert.Equal(t, events, result)
+
+	_, err = service.GetPRHistory(context.Background(), "missing")
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotFound, apiErr.Code)
+	mockRepo.AssertNotCalled(t, "ListPREvents", mock.Anything, "missing")
+}
+
+func TestPreviewPR_PersistsNothing(t *testing.T) {
+	service, mockRepo := createTestService()
+	roundRobin := NewRoundRobinStrategy()
+	service.strategy = roundRobin
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 1, FallbackTeams: []string{"platform"}}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3"}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "platform", "u1").Return([]string{"u3", "u9"}, nil)
+
+	first, err := service.PreviewPR(context.Background(), CreatePRRequest{AuthorID: "u1"})
+	assert.NoError(t, err)
+	second, err := service.PreviewPR(context.Background(), CreatePRRequest{AuthorID: "u1"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"u2"}, first.Reviewers)
+	assert.Equal(t, first.Reviewers, second.Reviewers, "Preview must not advance the round robin")
+	assert.Equal(t, []string{"u2", "u3", "u9"}, first.Eligible)
+	assert.Equal(t, StrategyRoundRobin, first.Strategy)
+	assert.Empty(t, roundRobin.lastPick)
+	mockRepo.AssertNotCalled(t, "CreatePRWithReviewers", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "GetPR", mock.Anything, mock.Anything)
+}
+
+func TestPreviewPR_UnknownAuthor(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetUser", mock.Anything, "ghost").Return(model.User{}, model.ErrNotFound)
+
+	_, err := service.PreviewPR(context.Background(), CreatePRRequest{AuthorID: "ghost"})
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.NotFound, apiErr.Code)
+}
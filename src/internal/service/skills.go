@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/store"
+	"sort"
+	"strings"
+)
+
+// UserSkills are the expertise tags of a user.
+type UserSkills struct {
+	UserID string   `json:"user_id"`
+	Skills []string `json:"skills"`
+}
+
+func (s *Service) GetUserSkills(ctx context.Context, userID string) (UserSkills, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return UserSkills{}, err
+	}
+	skills, err := s.repo.GetUserSkills(ctx, []string{userID})
+	if err != nil {
+		return UserSkills{}, err
+	}
+	return UserSkills{UserID: userID, Skills: append([]string{}, skills[userID]...)}, nil
+}
+
+// SetUserSkills replaces the skill tags of a user, an empty list clears them.
+func (s *Service) SetUserSkills(ctx context.Context, userID string, skills []string) (UserSkills, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return UserSkills{}, err
+	}
+	normalized, err := normalizeSkills(skills)
+	if err != nil {
+		return UserSkills{}, err
+	}
+	sort.Strings(normalized)
+	if err := s.repo.SetUserSkills(ctx, userID, normalized); err != nil {
+		return UserSkills{}, err
+	}
+	return UserSkills{UserID: userID, Skills: normalized}, nil
+}
+
+// normalizeSkills lowercases and deduplicates skill tags, keeping their order.
+func normalizeSkills(skills []string) ([]string, error) {
+	out := make([]string, 0, len(skills))
+	for _, sk := range skills {
+		tag := strings.ToLower(strings.TrimSpace(sk))
+		if tag == "" {
+			return nil, apiErrors.APIError{Code: apiErrors.InvalidArgument, Message: "skills must not be empty"}
+		}
+		if !contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out, nil
+}
+
+// uncoveredSkills returns the skills of required that none of reviewers has.
+func (s *Service) uncoveredSkills(ctx context.Context, required, reviewers []string) ([]string, error) {
+	if len(required) == 0 {
+		return nil, nil
+	}
+	var skills map[string][]string
+	if len(reviewers) > 0 {
+		var err error
+		if skills, err = s.repo.GetUserSkills(ctx, reviewers); err != nil {
+			return nil, err
+		}
+	}
+	var covered []string
+	for _, id := range reviewers {
+		covered = append(covered, skills[id]...)
+	}
+	return without(required, covered), nil
+}
+
+// SkillMatchingStrategy prefers candidates covering the skills in req.RequiredSkills: it greedily
+// picks whoever covers the most of the still uncovered skills, letting the wrapped strategy choose
+// among equally good candidates, and leaves the slots left after that to the wrapped strategy.
+// Without required skills it is the wrapped strategy.
+type SkillMatchingStrategy struct {
+	inner AssignmentStrategy
+	repo  store.Repository
+}
+
+func NewSkillMatchingStrategy(inner AssignmentStrategy, repo store.Repository) *SkillMatchingStrategy {
+	return &SkillMatchingStrategy{inner: inner, repo: repo}
+}
+
+func (s *SkillMatchingStrategy) Name() string { return s.inner.Name() }
+
+func (s *SkillMatchingStrategy) Pick(ctx context.Context, req AssignmentRequest) ([]string, error) {
+	if len(req.RequiredSkills) == 0 || len(req.Candidates) == 0 || req.Count <= 0 {
+		return s.inner.Pick(ctx, req)
+	}
+	skills, err := s.skills(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	uncovered := append([]string(nil), req.RequiredSkills...)
+	remaining := append([]string(nil), req.Candidates...)
+	var picked []string
+	for len(picked) < req.Count && len(uncovered) > 0 {
+		best, bestCount := []string(nil), 0
+		for _, id := range remaining {
+			n := len(uncovered) - len(without(uncovered, skills[id]))
+			switch {
+			case n > bestCount:
+				best, bestCount = []string{id}, n
+			case n == bestCount && n > 0:
+				best = append(best, id)
+			}
+		}
+		if bestCount == 0 {
+			break
+		}
+		sub := req.stage("skills", len(picked))
+		sub.Candidates, sub.Count = best, 1
+		got, err := s.inner.Pick(ctx, sub)
+		if err != nil {
+			return nil, err
+		}
+		if len(got) == 0 {
+			break
+		}
+		picked = append(picked, got[0])
+		remaining = without(remaining, got[:1])
+		uncovered = without(uncovered, skills[got[0]])
+	}
+
+	if rest := req.Count - len(picked); rest > 0 && len(remaining) > 0 {
+		sub := req.stage("skills_rest", 0)
+		sub.Candidates, sub.Count = remaining, rest
+		got, err := s.inner.Pick(ctx, sub)
+		if err != nil {
+			return nil, err
+		}
+		picked = append(picked, got...)
+	}
+	return picked, nil
+}
+
+// Inputs reports the skills of every candidate next to the inputs of the wrapped strategy.
+func (s *SkillMatchingStrategy) Inputs(ctx context.Context, req AssignmentRequest) (map[string]any, error) {
+	inputs := map[string]any{}
+	if r, ok := s.inner.(InputReporter); ok {
+		var err error
+		if inputs, err = r.Inputs(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.RequiredSkills) == 0 {
+		return inputs, nil
+	}
+	skills, err := s.skills(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	candidateSkills := make(map[string][]string, len(req.Candidates))
+	for _, id := range req.Candidates {
+		candidateSkills[id] = append([]string{}, skills[id]...)
+	}
+	inputs["skills"] = candidateSkills
+	inputs["required_skills"] = req.RequiredSkills
+	return inputs, nil
+}
+
+func (s *SkillMatchingStrategy) skills(ctx context.Context, req AssignmentRequest) (map[string][]string, error) {
+	if req.Skills != nil {
+		return req.Skills, nil
+	}
+	return s.repo.GetUserSkills(ctx, req.Candidates)
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/api/apiErrors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSkillMatchingStrategy_PrefersCoveringCandidates(t *testing.T) {
+	strategy := NewSkillMatchingStrategy(NewRoundRobinStrategy(), new(MockRepositories))
+
+	picked, err := strategy.Pick(context.Background(), AssignmentRequest{
+		Candidates:     []string{"u2", "u3", "u4", "u5"},
+		Count:          2,
+		RequiredSkills: []string{"go", "sql", "frontend"},
+		Skills:         map[string][]string{"u2": {"go"}, "u3": {"sql"}, "u4": {"go", "sql"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u4", "u2"}, picked, "u4 covers most skills, the rest is left to the wrapped strategy")
+}
+
+func TestSkillMatchingStrategy_GreedyCover(t *testing.T) {
+	strategy := NewSkillMatchingStrategy(NewRoundRobinStrategy(), new(MockRepositories))
+
+	picked, err := strategy.Pick(context.Background(), AssignmentRequest{
+		Candidates:     []string{"u2", "u3", "u4"},
+		Count:          2,
+		RequiredSkills: []string{"go", "sql", "frontend"},
+		Skills:         map[string][]string{"u2": {"go", "sql"}, "u3": {"go"}, "u4": {"frontend"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u4"}, picked)
+}
+
+func TestCreatePR_ReportsUncoveredSkills(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{TeamName: "backend", ReviewersCount: 1}, nil)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3"}, nil)
+	mockRepo.On("GetUserSkills", mock.Anything, []string{"u2", "u3"}).Return(map[string][]string{"u3": {"go"}}, nil)
+	mockRepo.On("GetUserSkills", mock.Anything, []string{"u3"}).Return(map[string][]string{"u3": {"go"}}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.MatchedBy(func(pr model.PullRequest) bool {
+		return assert.ObjectsAreEqual([]string{"go", "frontend"}, pr.RequiredSkills)
+	})).Return(nil)
+
+	pr, _, err := service.CreatePRExplained(context.Background(), CreatePRRequest{
+		PullRequestID:   "pr1",
+		PullRequestName: "Skilled PR",
+		AuthorID:        "u1",
+		RequiredSkills:  []string{" Go", "frontend", "go"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3"}, pr.Assigned)
+	assert.Equal(t, []string{"frontend"}, pr.UncoveredSkills)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetUserSkills(t *testing.T) {
+	service, mockRepo := createTestService()
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("SetUserSkills", mock.Anything, "u1", []string{"go", "sql"}).Return(nil)
+
+	skills, err := service.SetUserSkills(context.Background(), "u1", []string{"SQL", " go ", "sql"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, UserSkills{UserID: "u1", Skills: []string{"go", "sql"}}, skills)
+
+	_, err = service.SetUserSkills(context.Background(), "u1", []string{"go", " "})
+
+	var apiErr apiErrors.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apiErrors.InvalidArgument, apiErr.Code)
+	mockRepo.AssertNumberOfCalls(t, "SetUserSkills", 1)
+}
+
+func TestDeactivateTeamUsers_PrefersRequiredSkills(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.seeds = NewSeedSource(1)
+
+	team := model.Team{TeamName: "backend", Members: []model.TeamMember{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}}}
+	var prs []model.PullRequest
+	for i := 0; i < 6; i++ {
+		prs = append(prs, model.PullRequest{
+			PullRequestID:  fmt.Sprintf("pr%d", i),
+			AuthorID:       "u1",
+			Status:         model.StatusOpen,
+			Assigned:       []string{"u2"},
+			RequiredSkills: []string{"go"},
+		})
+	}
+	mockRepo.On("GetTeam", mock.Anything, "backend").Return(team, nil)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "").Return([]string{"u1", "u2", "u3", "u4"}, nil)
+	mockRepo.On("GetOpenReviewCounts", mock.Anything, []string{"u1", "u3", "u4"}).Return(map[string]int{}, nil)
+	mockRepo.On("GetOpenPRsForReviewers", mock.Anything, []string{"u2"}).Return(prs, nil)
+	mockRepo.On("GetUserSkills", mock.Anything, mock.Anything).Return(map[string][]string{"u2": {"go"}, "u4": {"go"}}, nil)
+	mockRepo.On("DeactivateUsersAndReassign", mock.Anything, "backend", []string{"u2"}, mock.Anything).Return(nil)
+
+	result, err := service.DeactivateTeamUsers(context.Background(), "backend", []string{"u2"})
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Reassigned, len(prs))
+	for _, r := range result.Reassigned {
+		assert.Equal(t, "u4", r.NewReviewerID, "the only other go reviewer should replace u2 on %s", r.PullRequestID)
+	}
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/store"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+const (
+	StrategyRandom      = "random"
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastLoaded = "least_loaded"
+)
+
+// AssignmentRequest describes a single reviewer selection.
+// For reassignments Author carries only the author's user_id.
+// Loads optionally carries preloaded open review counts for batch operations.
+// DryRun asks strategies not to update their state, the pick is only previewed.
+// Seed is the per-PR seed every random choice of the pick is made with, it is recorded
+// with the assignment so the pick can be replayed.
+// RequiredSkills are the skills still to be covered, Skills optionally carries the preloaded skills of candidates.
+// MinSeniors is the number of senior reviewers still required, Roles optionally carries the preloaded roles of candidates.
+type AssignmentRequest struct {
+	PR             model.PullRequest
+	Author         model.User
+	Candidates     []string
+	Count          int
+	Loads          map[string]int
+	DryRun         bool
+	Seed           int64
+	RequiredSkills []string
+	Skills         map[string][]string
+	MinSeniors     int
+	Roles          map[string]string
+}
+
+// rng returns a generator private to the pick, seeded with req.Seed.
+func (req AssignmentRequest) rng() *rand.Rand {
+	return rand.New(rand.NewSource(req.Seed))
+}
+
+// stage returns req for the i-th sub-pick of stage, seeded with a seed derived from req.Seed
+// so that the sub-picks of one pick do not repeat the same random choices.
+func (req AssignmentRequest) stage(name string, i int) AssignmentRequest {
+	req.Seed = subSeed(req.Seed, name, i)
+	return req
+}
+
+// AssignmentStrategy picks up to req.Count reviewers from req.Candidates, most preferred first.
+type AssignmentStrategy interface {
+	Name() string
+	Pick(ctx context.Context, req AssignmentRequest) ([]string, error)
+}
+
+// InputReporter is implemented by strategies that can report the inputs a pick is based on,
+// it is used to explain assignments.
+type InputReporter interface {
+	Inputs(ctx context.Context, req AssignmentRequest) (map[string]any, error)
+}
+
+// NewStrategy builds a strategy by its configuration name.
+func NewStrategy(name string, repo store.Repository) (AssignmentStrategy, error) {
+	switch name {
+	case StrategyRandom:
+		return NewRandomStrategy(), nil
+	case StrategyRoundRobin:
+		return NewRoundRobinStrategy(), nil
+	case StrategyLeastLoaded:
+		return NewLeastLoadedStrategy(repo), nil
+	default:
+		return nil, fmt.Errorf("unknown assignment strategy %q", name)
+	}
+}
+
+// BuiltinStrategies returns one instance of every strategy shipped with the service.
+func BuiltinStrategies(repo store.Repository) []AssignmentStrategy {
+	return []AssignmentStrategy{
+		NewRandomStrategy(),
+		NewRoundRobinStrategy(),
+		NewLeastLoadedStrategy(repo),
+	}
+}
+
+// RandomStrategy picks candidates uniformly at random.
+type RandomStrategy struct{}
+
+func NewRandomStrategy() *RandomStrategy {
+	return &RandomStrategy{}
+}
+
+func (s *RandomStrategy) Name() string { return StrategyRandom }
+
+func (s *RandomStrategy) Pick(_ context.Context, req AssignmentRequest) ([]string, error) {
+	if len(req.Candidates) == 0 || req.Count <= 0 {
+		return nil, nil
+	}
+	rnd := req.rng()
+	if req.Count == 1 {
+		return []string{req.Candidates[rnd.Intn(len(req.Candidates))]}, nil
+	}
+	return chooseUpToN(rnd, req.Candidates, req.Count), nil
+}
+
+// RoundRobinStrategy prefers candidates it has not picked for the longest time.
+type RoundRobinStrategy struct {
+	mu       sync.Mutex
+	seq      int64
+	lastPick map[string]int64
+}
+
+func NewRoundRobinStrategy() *RoundRobinStrategy {
+	return &RoundRobinStrategy{lastPick: make(map[string]int64)}
+}
+
+func (s *RoundRobinStrategy) Name() string { return StrategyRoundRobin }
+
+func (s *RoundRobinStrategy) Pick(_ context.Context, req AssignmentRequest) ([]string, error) {
+	if len(req.Candidates) == 0 || req.Count <= 0 {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := append([]string(nil), req.Candidates...)
+	sort.Slice(out, func(i, j int) bool {
+		if s.lastPick[out[i]] != s.lastPick[out[j]] {
+			return s.lastPick[out[i]] < s.lastPick[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	if len(out) > req.Count {
+		out = out[:req.Count]
+	}
+	if req.DryRun {
+		return out, nil
+	}
+	for _, id := range out {
+		s.seq++
+		s.lastPick[id] = s.seq
+	}
+	return out, nil
+}
+
+// Inputs reports the last pick sequence number of every candidate, zero for never picked ones.
+func (s *RoundRobinStrategy) Inputs(_ context.Context, req AssignmentRequest) (map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lastPicked := make(map[string]int64, len(req.Candidates))
+	for _, id := range req.Candidates {
+		lastPicked[id] = s.lastPick[id]
+	}
+	return map[string]any{"last_picked": lastPicked}, nil
+}
+
+// LeastLoadedStrategy prefers candidates with the fewest open reviews, ties are broken randomly.
+type LeastLoadedStrategy struct {
+	repo store.Repository
+}
+
+func NewLeastLoadedStrategy(repo store.Repository) *LeastLoadedStrategy {
+	return &LeastLoadedStrategy{repo: repo}
+}
+
+func (s *LeastLoadedStrategy) Name() string { return StrategyLeastLoaded }
+
+func (s *LeastLoadedStrategy) Pick(ctx context.Context, req AssignmentRequest) ([]string, error) {
+	if len(req.Candidates) == 0 || req.Count <= 0 {
+		return nil, nil
+	}
+	loads := req.Loads
+	if loads == nil {
+		var err error
+		if loads, err = s.repo.GetOpenReviewCounts(ctx, req.Candidates); err != nil {
+			return nil, err
+		}
+	}
+	return chooseLeastLoaded(req.rng(), req.Candidates, loads, req.Count), nil
+}
+
+// Inputs reports the open review count of every candidate.
+func (s *LeastLoadedStrategy) Inputs(ctx context.Context, req AssignmentRequest) (map[string]any, error) {
+	loads := req.Loads
+	if loads == nil {
+		var err error
+		if loads, err = s.repo.GetOpenReviewCounts(ctx, req.Candidates); err != nil {
+			return nil, err
+		}
+	}
+	openReviews := make(map[string]int, len(req.Candidates))
+	for _, id := range req.Candidates {
+		openReviews[id] = loads[id]
+	}
+	return map[string]any{"open_reviews": openReviews}, nil
+}
+
+func chooseUpToN(r *rand.Rand, items []string, n int) []string {
+	if len(items) <= n {
+		out := append([]string(nil), items...)
+		r.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+		return out
+	}
+	out := append([]string(nil), items...)
+	r.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	return out[:n]
+}
+
+// chooseLeastLoaded returns up to n items with the smallest load, ties are broken randomly.
+func chooseLeastLoaded(r *rand.Rand, items []string, loads map[string]int, n int) []string {
+	out := append([]string(nil), items...)
+	r.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	sort.SliceStable(out, func(i, j int) bool { return loads[out[i]] < loads[out[j]] })
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewStrategy(t *testing.T) {
+	mockRepo := new(MockRepositories)
+
+	for _, name := range []string{StrategyRandom, StrategyRoundRobin, StrategyLeastLoaded} {
+		strategy, err := NewStrategy(name, mockRepo)
+		assert.NoError(t, err)
+		assert.Equal(t, name, strategy.Name())
+	}
+
+	_, err := NewStrategy("unknown", mockRepo)
+	assert.Error(t, err)
+}
+
+func TestRandomStrategy_Pick(t *testing.T) {
+	strategy := NewRandomStrategy()
+	candidates := []string{"u2", "u3", "u4"}
+
+	picked, err := strategy.Pick(context.Background(), AssignmentRequest{Candidates: candidates, Count: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, picked, 2)
+	assert.NotEqual(t, picked[0], picked[1])
+	for _, id := range picked {
+		assert.Contains(t, candidates, id)
+	}
+}
+
+func TestRandomStrategy_EmptyPool(t *testing.T) {
+	strategy := NewRandomStrategy()
+
+	picked, err := strategy.Pick(context.Background(), AssignmentRequest{Count: 2})
+
+	assert.NoError(t, err)
+	assert.Empty(t, picked)
+}
+
+func TestRoundRobinStrategy_Rotates(t *testing.T) {
+	strategy := NewRoundRobinStrategy()
+	req := AssignmentRequest{Candidates: []string{"u3", "u2", "u4"}, Count: 2}
+
+	first, err := strategy.Pick(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u3"}, first)
+
+	second, err := strategy.Pick(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u4", "u2"}, second)
+
+	third, err := strategy.Pick(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3", "u4"}, third)
+}
+
+func TestLeastLoadedStrategy_PrefersIdleReviewers(t *testing.T) {
+	mockRepo := new(MockRepositories)
+	strategy := NewLeastLoadedStrategy(mockRepo)
+	candidates := []string{"u2", "u3", "u4"}
+
+	mockRepo.On("GetOpenReviewCounts", mock.Anything, candidates).Return(map[string]int{"u2": 8, "u4": 1}, nil)
+
+	picked, err := strategy.Pick(context.Background(), AssignmentRequest{
+		PR:         model.PullRequest{PullRequestID: "pr1"},
+		Author:     model.User{UserID: "u1"},
+		Candidates: candidates,
+		Count:      2,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3", "u4"}, picked)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChooseLeastLoaded_TiesBrokenRandomly(t *testing.T) {
+	loads := map[string]int{"u1": 3, "u2": 0, "u3": 0}
+	seen := map[string]bool{}
+
+	for seed := int64(0); seed < 50; seed++ {
+		picked := chooseLeastLoaded(rand.New(rand.NewSource(seed)), []string{"u1", "u2", "u3"}, loads, 1)
+		assert.Len(t, picked, 1)
+		assert.NotEqual(t, "u1", picked[0])
+		seen[picked[0]] = true
+	}
+
+	assert.True(t, seen["u2"] && seen["u3"], "both least loaded candidates should be picked at some point")
+}
+
+func TestRandomStrategy_ReplaysSeed(t *testing.T) {
+	strategy := NewRandomStrategy()
+	req := AssignmentRequest{Candidates: []string{"u2", "u3", "u4", "u5", "u6"}, Count: 2, Seed: 42}
+
+	first, err := strategy.Pick(context.Background(), req)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		again, err := strategy.Pick(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, first, again)
+	}
+}
+
+func TestSeedSource_Reproducible(t *testing.T) {
+	a, b := NewSeedSource(7), NewSeedSource(7)
+	for _, prID := range []string{"pr1", "pr2", "pr1"} {
+		assert.Equal(t, a.Derive(prID, false), b.Derive(prID, false))
+	}
+
+	assert.NotEqual(t, NewSeedSource(7).Derive("pr1", false), NewSeedSource(7).Derive("pr2", false))
+	assert.NotEqual(t, NewSeedSource(7).Derive("pr1", false), NewSeedSource(8).Derive("pr1", false))
+}
+
+func TestAssignmentRequest_StageSeeds(t *testing.T) {
+	req := AssignmentRequest{Seed: 42}
+
+	assert.Equal(t, req.stage("team", 0).Seed, req.stage("team", 0).Seed)
+	assert.NotEqual(t, req.Seed, req.stage("team", 0).Seed)
+	assert.NotEqual(t, req.stage("team", 0).Seed, req.stage("team", 1).Seed)
+	assert.NotEqual(t, req.stage("team", 0).Seed, req.stage("fallback", 0).Seed)
+	assert.NotEqual(t, req.stage("team", 0).Seed, AssignmentRequest{Seed: 43}.stage("team", 0).Seed)
+}
+
+func TestSeedSource_DryRunKeepsSequence(t *testing.T) {
+	a, b := NewSeedSource(3), NewSeedSource(3)
+
+	assert.Equal(t, a.Derive("pr1", false), b.Derive("pr1", false))
+	preview := b.Derive("pr2", true)
+	assert.Equal(t, preview, b.Derive("pr2", true), "dry runs should not use up the seed")
+
+	next := a.Derive("pr2", false)
+	assert.Equal(t, next, b.Derive("pr2", false), "a preview in between should not change later seeds")
+	assert.Equal(t, next, preview)
+}
+
+func TestSeedSource_ConcurrentUse(t *testing.T) {
+	src := NewSeedSource(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				src.Derive("pr1", false)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCreatePR_ReplaysRecordedSeed(t *testing.T) {
+	create := func(seeds SeedSource) model.PullRequest {
+		service, mockRepo := createTestService()
+		service.seeds = seeds
+
+		mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+		mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+		mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+		// the store lists candidates ordered by user_id
+		mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3", "u4", "u5", "u6"}, nil)
+		mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+		pr, err := service.CreatePR(context.Background(), "pr1", "Replay", "u1")
+		assert.NoError(t, err)
+		return pr
+	}
+
+	for base := int64(0); base < 20; base++ {
+		recorded := create(NewSeedSource(base))
+		replayed := create(NewMockSeedSource(recorded.Assignment.Seed))
+
+		assert.Equal(t, recorded.Assignment.Seed, replayed.Assignment.Seed)
+		assert.Equal(t, recorded.Assigned, replayed.Assigned, "the recorded seed should replay the pick")
+	}
+}
+
+func TestPreviewPR_MatchesLaterCreate(t *testing.T) {
+	service, mockRepo := createTestService()
+	service.seeds = NewSeedSource(5)
+
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockRepo.On("GetPR", mock.Anything, "pr1").Return(model.PullRequest{}, model.ErrNotFound)
+	mockRepo.On("GetTeamSettings", mock.Anything, "backend").Return(model.TeamSettings{}, model.ErrNotFound)
+	mockRepo.On("GetActiveTeamMembersExcept", mock.Anything, "backend", "u1").Return([]string{"u2", "u3", "u4", "u5", "u6"}, nil)
+	mockRepo.On("CreatePRWithReviewers", mock.Anything, mock.AnythingOfType("model.PullRequest")).Return(nil)
+
+	req := CreatePRRequest{PullRequestID: "pr1", PullRequestName: "Preview", AuthorID: "u1"}
+	first, err := service.PreviewPR(context.Background(), req)
+	assert.NoError(t, err)
+	second, err := service.PreviewPR(context.Background(), req)
+	assert.NoError(t, err)
+	pr, _, err := service.CreatePRExplained(context.Background(), req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, first.Seed, pr.Assignment.Seed, "previews should not advance the seed source")
+	assert.Equal(t, first.Reviewers, pr.Assigned)
+}
@@ -11,15 +11,42 @@ import (
 type Repository interface {
 	CreateTeam(ctx context.Context, t model.Team) (model.Team, error)
 	GetTeam(ctx context.Context, teamName string) (model.Team, error)
+	GetTeamSettings(ctx context.Context, teamName string) (model.TeamSettings, error)
+	UpsertTeamSettings(ctx context.Context, settings model.TeamSettings) (model.TeamSettings, error)
 	SetUserIsActive(ctx context.Context, userID string, isActive bool) (model.User, error)
 	GetUser(ctx context.Context, userID string) (model.User, error)
 	GetActiveTeamMembersExcept(ctx context.Context, teamName, excludeUserID string) ([]string, error)
+	CreateAbsence(ctx context.Context, a model.Absence) (model.Absence, error)
+	GetAbsence(ctx context.Context, absenceID int64) (model.Absence, error)
+	ListAbsences(ctx context.Context, userID string) ([]model.Absence, error)
+	UpdateAbsence(ctx context.Context, a model.Absence) (model.Absence, error)
+	DeleteAbsence(ctx context.Context, absenceID int64) error
 	CreatePRWithReviewers(ctx context.Context, pr model.PullRequest) error
 	GetPR(ctx context.Context, prID string) (model.PullRequest, error)
 	UpdatePR(ctx context.Context, pr model.PullRequest) error
+	ReplaceReviewer(ctx context.Context, swap model.ReviewerSwap, version int64) (model.PullRequest, error)
+	AssignReviewer(ctx context.Context, c model.ReviewerChange) (model.PullRequest, error)
+	UnassignReviewer(ctx context.Context, c model.ReviewerChange) (model.PullRequest, error)
+	TransitionPR(ctx context.Context, t model.PRTransition) (model.PullRequest, error)
+	AddReview(ctx context.Context, review model.Review, ifVersion int64) (model.PullRequest, error)
+	ListPREvents(ctx context.Context, prID string) ([]model.PREvent, error)
 	GetAssignedPRsForUser(ctx context.Context, userID string) ([]model.PullRequestShort, error)
+	GetOpenPRsForReviewers(ctx context.Context, userIDs []string) ([]model.PullRequest, error)
+	DeactivateUsersAndReassign(ctx context.Context, teamName string, userIDs []string, swaps []model.ReviewerSwap) error
 	GetReviewStats(ctx context.Context) (map[string]int, error)
 	GetPRReviewStats(ctx context.Context) (map[string]int, error)
+	GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error)
+	ListCodeOwnerRules(ctx context.Context) ([]model.CodeOwnerRule, error)
+	ReplaceCodeOwnerRules(ctx context.Context, rules []model.CodeOwnerRule) error
+	GetUserSkills(ctx context.Context, userIDs []string) (map[string][]string, error)
+	SetUserSkills(ctx context.Context, userID string, skills []string) error
+	SetUserMaxOpenReviews(ctx context.Context, userID string, maxOpen *int) (model.User, error)
+	GetUsersAtCapacity(ctx context.Context, userIDs []string) ([]string, error)
+	GetMaxOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error)
+	GetRecentPairCounts(ctx context.Context, authorID, excludePRID string, window int) (map[string]int, error)
+	GetTeamPairCounts(ctx context.Context, teamName string) ([]model.PairCount, error)
+	SetUserRole(ctx context.Context, teamName, userID, role string) (model.User, error)
+	GetUserRoles(ctx context.Context, userIDs []string) (map[string]string, error)
 }
 
 type Repositories struct {
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+
+	"go.uber.org/zap"
+)
+
+func (r *Repositories) CreateAbsence(ctx context.Context, a model.Absence) (model.Absence, error) {
+	r.Log.Debug("CreateAbsence: start", zap.String("user", a.UserID))
+	if err := r.DB.QueryRowContext(ctx,
+		`INSERT INTO user_absences(user_id, starts_at, ends_at, reason) VALUES($1,$2,$3,$4) RETURNING absence_id`,
+		a.UserID, a.StartsAt, a.EndsAt, a.Reason).Scan(&a.AbsenceID); err != nil {
+		r.Log.Error("CreateAbsence: insert failed", zap.String("user", a.UserID), zap.Error(err))
+		return model.Absence{}, err
+	}
+	r.Log.Info("CreateAbsence: success", zap.String("user", a.UserID), zap.Int64("absence_id", a.AbsenceID))
+	return a, nil
+}
+
+func (r *Repositories) GetAbsence(ctx context.Context, absenceID int64) (model.Absence, error) {
+	r.Log.Debug("GetAbsence: start", zap.Int64("absence_id", absenceID))
+	var a model.Absence
+	if err := r.DB.QueryRowContext(ctx,
+		`SELECT absence_id, user_id, starts_at, ends_at, reason FROM user_absences WHERE absence_id=$1`, absenceID).
+		Scan(&a.AbsenceID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.Log.Debug("GetAbsence: not found", zap.Int64("absence_id", absenceID))
+			return model.Absence{}, model.ErrNotFound
+		}
+		r.Log.Error("GetAbsence: query failed", zap.Error(err))
+		return model.Absence{}, err
+	}
+	return a, nil
+}
+
+func (r *Repositories) ListAbsences(ctx context.Context, userID string) ([]model.Absence, error) {
+	r.Log.Debug("ListAbsences: start", zap.String("user", userID))
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT absence_id, user_id, starts_at, ends_at, reason FROM user_absences WHERE user_id=$1 ORDER BY starts_at`, userID)
+	if err != nil {
+		r.Log.Error("ListAbsences: query failed", zap.Error(err))
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.Log.Error("ListAbsences: close rows failed", zap.Error(err))
+		}
+	}(rows)
+
+	var out []model.Absence
+	for rows.Next() {
+		var a model.Absence
+		if err := rows.Scan(&a.AbsenceID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason); err != nil {
+			r.Log.Error("ListAbsences: scan failed", zap.Error(err))
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("ListAbsences: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("ListAbsences: success", zap.Int("count", len(out)))
+	return out, nil
+}
+
+func (r *Repositories) UpdateAbsence(ctx context.Context, a model.Absence) (model.Absence, error) {
+	r.Log.Debug("UpdateAbsence: start", zap.Int64("absence_id", a.AbsenceID))
+	res, err := r.DB.ExecContext(ctx,
+		`UPDATE user_absences SET starts_at=$2, ends_at=$3, reason=$4 WHERE absence_id=$1`,
+		a.AbsenceID, a.StartsAt, a.EndsAt, a.Reason)
+	if err != nil {
+		r.Log.Error("UpdateAbsence: update failed", zap.Error(err))
+		return model.Absence{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		r.Log.Debug("UpdateAbsence: not found", zap.Int64("absence_id", a.AbsenceID))
+		return model.Absence{}, model.ErrNotFound
+	}
+	r.Log.Info("UpdateAbsence: success", zap.Int64("absence_id", a.AbsenceID))
+	return a, nil
+}
+
+func (r *Repositories) DeleteAbsence(ctx context.Context, absenceID int64) error {
+	r.Log.Debug("DeleteAbsence: start", zap.Int64("absence_id", absenceID))
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM user_absences WHERE absence_id=$1`, absenceID)
+	if err != nil {
+		r.Log.Error("DeleteAbsence: delete failed", zap.Error(err))
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		r.Log.Debug("DeleteAbsence: not found", zap.Int64("absence_id", absenceID))
+		return model.ErrNotFound
+	}
+	r.Log.Info("DeleteAbsence: success", zap.Int64("absence_id", absenceID))
+	return nil
+}
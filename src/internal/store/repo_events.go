@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// appendEvent records a PR history event in tx, so it is committed together with the change it describes.
+func (r *Repositories) appendEvent(ctx context.Context, tx *sql.Tx, prID, eventType string, data map[string]any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO pr_events(pull_request_id, event_type, data) VALUES($1,$2,$3)`,
+		prID, eventType, payload); err != nil {
+		r.Log.Error("appendEvent: insert failed", zap.String("pr_id", prID), zap.String("type", eventType), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ListPREvents returns the history of a PR, oldest event first.
+func (r *Repositories) ListPREvents(ctx context.Context, prID string) ([]model.PREvent, error) {
+	r.Log.Debug("ListPREvents: start", zap.String("pr_id", prID))
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT event_id, pull_request_id, event_type, data, created_at FROM pr_events WHERE pull_request_id=$1 ORDER BY event_id`, prID)
+	if err != nil {
+		r.Log.Error("ListPREvents: query failed", zap.Error(err))
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.Log.Error("ListPREvents: close rows failed", zap.Error(err))
+		}
+	}(rows)
+
+	out := []model.PREvent{}
+	for rows.Next() {
+		var e model.PREvent
+		var payload []byte
+		if err := rows.Scan(&e.EventID, &e.PullRequestID, &e.Type, &payload, &e.CreatedAt); err != nil {
+			r.Log.Error("ListPREvents: scan failed", zap.Error(err))
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &e.Data); err != nil {
+			r.Log.Error("ListPREvents: decode data failed", zap.Int64("event_id", e.EventID), zap.Error(err))
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("ListPREvents: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("ListPREvents: success", zap.Int("count", len(out)))
+	return out, nil
+}
+
+func assignmentEventData(assigned, fallback []string, a model.AssignmentInfo) map[string]any {
+	if fallback == nil {
+		fallback = []string{}
+	}
+	data := map[string]any{"reviewers": assigned, "fallback_reviewers": fallback, "strategy": a.Strategy}
+	withSeed(data, a)
+	return data
+}
+
+func swapEventData(sw model.ReviewerSwap) map[string]any {
+	data := map[string]any{"from": sw.OldUserID, "to": sw.NewUserID, "fallback": sw.IsFallback, "strategy": sw.Assignment.Strategy}
+	withSeed(data, sw.Assignment)
+	return data
+}
+
+// withSeed records the assignment seed as a string, JSON numbers can't hold every int64 exactly.
+func withSeed(data map[string]any, a model.AssignmentInfo) {
+	if a.Strategy != model.AssignedManually {
+		data["seed"] = strconv.FormatInt(a.Seed, 10)
+	}
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ReserveIdempotencyKey claims rec.Key for a new request. If the key is already taken and not expired,
+// it returns the stored record and false.
+func (r *Repositories) ReserveIdempotencyKey(ctx context.Context, rec model.IdempotencyRecord) (model.IdempotencyRecord, bool, error) {
+	r.Log.Debug("ReserveIdempotencyKey: start", zap.String("key", rec.Key), zap.String("path", rec.Path))
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		r.Log.Error("ReserveIdempotencyKey: begin tx failed", zap.Error(err))
+		return model.IdempotencyRecord{}, false, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.Log.Warn("ReserveIdempotencyKey: rollback failed", zap.Error(err))
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE idempotency_key=$1 AND method=$2 AND path=$3 AND expires_at <= now()`,
+		rec.Key, rec.Method, rec.Path); err != nil {
+		r.Log.Error("ReserveIdempotencyKey: delete expired failed", zap.Error(err))
+		return model.IdempotencyRecord{}, false, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO idempotency_keys(idempotency_key, method, path, request_hash, expires_at)
+		VALUES($1,$2,$3,$4,$5)
+		ON CONFLICT DO NOTHING
+	`, rec.Key, rec.Method, rec.Path, rec.RequestHash, rec.ExpiresAt)
+	if err != nil {
+		r.Log.Error("ReserveIdempotencyKey: insert failed", zap.Error(err))
+		return model.IdempotencyRecord{}, false, err
+	}
+
+	if n, _ := res.RowsAffected(); n == 1 {
+		if err := tx.Commit(); err != nil {
+			r.Log.Error("ReserveIdempotencyKey: commit failed", zap.Error(err))
+			return model.IdempotencyRecord{}, false, err
+		}
+		r.Log.Debug("ReserveIdempotencyKey: reserved", zap.String("key", rec.Key))
+		return rec, true, nil
+	}
+
+	stored := model.IdempotencyRecord{Key: rec.Key, Method: rec.Method, Path: rec.Path}
+	var headers []byte
+	if err := tx.QueryRowContext(ctx, `
+		SELECT request_hash, status_code, response_headers, response_body, expires_at
+		FROM idempotency_keys WHERE idempotency_key=$1 AND method=$2 AND path=$3
+	`, rec.Key, rec.Method, rec.Path).Scan(&stored.RequestHash, &stored.StatusCode, &headers, &stored.Body, &stored.ExpiresAt); err != nil {
+		r.Log.Error("ReserveIdempotencyKey: select stored failed", zap.Error(err))
+		return model.IdempotencyRecord{}, false, err
+	}
+	if err := json.Unmarshal(headers, &stored.Headers); err != nil {
+		r.Log.Error("ReserveIdempotencyKey: decode headers failed", zap.Error(err))
+		return model.IdempotencyRecord{}, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Log.Error("ReserveIdempotencyKey: commit failed", zap.Error(err))
+		return model.IdempotencyRecord{}, false, err
+	}
+	r.Log.Debug("ReserveIdempotencyKey: key taken", zap.String("key", rec.Key), zap.Int("status", stored.StatusCode))
+	return stored, false, nil
+}
+
+// SaveIdempotentResponse stores the response of a reserved request for replay.
+func (r *Repositories) SaveIdempotentResponse(ctx context.Context, rec model.IdempotencyRecord) error {
+	r.Log.Debug("SaveIdempotentResponse: start", zap.String("key", rec.Key), zap.Int("status", rec.StatusCode))
+	headers, err := json.Marshal(rec.Headers)
+	if err != nil {
+		return err
+	}
+	if _, err := r.DB.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status_code=$4, response_headers=$5, response_body=$6
+		WHERE idempotency_key=$1 AND method=$2 AND path=$3
+	`, rec.Key, rec.Method, rec.Path, rec.StatusCode, headers, rec.Body); err != nil {
+		r.Log.Error("SaveIdempotentResponse: update failed", zap.Error(err))
+		return err
+	}
+	r.Log.Debug("SaveIdempotentResponse: success", zap.String("key", rec.Key))
+	return nil
+}
+
+// ReleaseIdempotencyKey drops a reservation so the request can be retried.
+func (r *Repositories) ReleaseIdempotencyKey(ctx context.Context, key, method, path string) error {
+	r.Log.Debug("ReleaseIdempotencyKey: start", zap.String("key", key))
+	if _, err := r.DB.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE idempotency_key=$1 AND method=$2 AND path=$3`, key, method, path); err != nil {
+		r.Log.Error("ReleaseIdempotencyKey: delete failed", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys purges records whose TTL has passed.
+func (r *Repositories) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		r.Log.Error("DeleteExpiredIdempotencyKeys: delete failed", zap.Error(err))
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	r.Log.Debug("DeleteExpiredIdempotencyKeys: success", zap.Int64("deleted", n))
+	return n, nil
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// ListCodeOwnerRules returns the code owner rules in order.
+func (r *Repositories) ListCodeOwnerRules(ctx context.Context) ([]model.CodeOwnerRule, error) {
+	r.Log.Debug("ListCodeOwnerRules: start")
+	rows, err := r.DB.QueryContext(ctx, `SELECT pattern, team_name, user_id FROM code_owner_rules ORDER BY position`)
+	if err != nil {
+		r.Log.Error("ListCodeOwnerRules: query failed", zap.Error(err))
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.Log.Error("ListCodeOwnerRules: close rows failed", zap.Error(err))
+		}
+	}(rows)
+
+	out := []model.CodeOwnerRule{}
+	for rows.Next() {
+		var rule model.CodeOwnerRule
+		var teamName, userID sql.NullString
+		if err := rows.Scan(&rule.Pattern, &teamName, &userID); err != nil {
+			r.Log.Error("ListCodeOwnerRules: scan failed", zap.Error(err))
+			return nil, err
+		}
+		rule.TeamName, rule.UserID = teamName.String, userID.String
+		out = append(out, rule)
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("ListCodeOwnerRules: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("ListCodeOwnerRules: success", zap.Int("count", len(out)))
+	return out, nil
+}
+
+// ReplaceCodeOwnerRules swaps the whole rule set for rules, keeping their order.
+func (r *Repositories) ReplaceCodeOwnerRules(ctx context.Context, rules []model.CodeOwnerRule) error {
+	r.Log.Debug("ReplaceCodeOwnerRules: start", zap.Int("count", len(rules)))
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		r.Log.Error("ReplaceCodeOwnerRules: begin tx failed", zap.Error(err))
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.Log.Warn("ReplaceCodeOwnerRules: rollback failed", zap.Error(err))
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM code_owner_rules`); err != nil {
+		r.Log.Error("ReplaceCodeOwnerRules: delete failed", zap.Error(err))
+		return err
+	}
+	for i, rule := range rules {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO code_owner_rules(position, pattern, team_name, user_id) VALUES($1,$2,NULLIF($3,''),NULLIF($4,''))`,
+			i, rule.Pattern, rule.TeamName, rule.UserID); err != nil {
+			r.Log.Error("ReplaceCodeOwnerRules: insert failed", zap.String("pattern", rule.Pattern), zap.Error(err))
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Log.Error("ReplaceCodeOwnerRules: commit failed", zap.Error(err))
+		return err
+	}
+	r.Log.Info("ReplaceCodeOwnerRules: success", zap.Int("count", len(rules)))
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
 	"time"
 
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -35,21 +36,36 @@ func (r *Repositories) CreatePRWithReviewers(ctx context.Context, pr model.PullR
 	}()
 
 	_, err = tx.ExecContext(ctx,
-		`INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, status, created_at) VALUES($1,$2,$3,'OPEN', now())`,
-		pr.PullRequestID, pr.PullRequestName, pr.AuthorID)
+		`INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, status, created_at, changed_files, required_skills)
+		 VALUES($1,$2,$3,$4, now(), COALESCE($5::TEXT[], '{}'), COALESCE($6::TEXT[], '{}'))`,
+		pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, pq.Array(pr.ChangedFiles), pq.Array(pr.RequiredSkills))
 	if err != nil {
 		r.Log.Error("CreatePRWithReviewers: insert pull_requests failed", zap.String("pr_id", pr.PullRequestID), zap.Error(err))
 		return err
 	}
 
 	for _, u := range pr.Assigned {
-		if _, err := tx.ExecContext(ctx, `INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES($1,$2)`, pr.PullRequestID, u); err != nil {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO pr_reviewers(pull_request_id, user_id, is_fallback) VALUES($1,$2,$3)`,
+			pr.PullRequestID, u, contains(pr.Fallback, u)); err != nil {
 			r.Log.Error("CreatePRWithReviewers: insert pr_reviewers failed", zap.String("pr_id", pr.PullRequestID), zap.String("user", u), zap.Error(err))
 			return err
 		}
 		r.Log.Debug("CreatePRWithReviewers: inserted reviewer", zap.String("pr_id", pr.PullRequestID), zap.String("reviewer", u))
 	}
 
+	if err := r.appendEvent(ctx, tx, pr.PullRequestID, model.EventCreated, map[string]any{
+		"pull_request_name": pr.PullRequestName,
+		"author_id":         pr.AuthorID,
+		"status":            pr.Status,
+	}); err != nil {
+		return err
+	}
+	if len(pr.Assigned) > 0 {
+		if err := r.appendEvent(ctx, tx, pr.PullRequestID, model.EventReviewersAssigned, assignmentEventData(pr.Assigned, pr.Fallback, pr.Assignment)); err != nil {
+			return err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		r.Log.Error("CreatePRWithReviewers: commit failed", zap.String("pr_id", pr.PullRequestID), zap.Error(err))
 		return err
@@ -62,9 +78,9 @@ func (r *Repositories) CreatePRWithReviewers(ctx context.Context, pr model.PullR
 func (r *Repositories) GetPR(ctx context.Context, prID string) (model.PullRequest, error) {
 	r.Log.Debug("GetPR: start", zap.String("pr_id", prID))
 	var p model.PullRequest
-	var mergedAt sql.NullTime
-	if err := r.DB.QueryRowContext(ctx, `SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at FROM pull_requests WHERE pull_request_id=$1`, prID).
-		Scan(&p.PullRequestID, &p.PullRequestName, &p.AuthorID, &p.Status, &p.CreatedAt, &mergedAt); err != nil {
+	var mergedAt, closedAt sql.NullTime
+	if err := r.DB.QueryRowContext(ctx, `SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, version, force_merged, changed_files, required_skills FROM pull_requests WHERE pull_request_id=$1`, prID).
+		Scan(&p.PullRequestID, &p.PullRequestName, &p.AuthorID, &p.Status, &p.CreatedAt, &mergedAt, &closedAt, &p.Version, &p.ForceMerged, pq.Array(&p.ChangedFiles), pq.Array(&p.RequiredSkills)); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.Log.Debug("GetPR: not found", zap.String("pr_id", prID))
 			return model.PullRequest{}, model.ErrNotFound
@@ -77,27 +93,14 @@ func (r *Repositories) GetPR(ctx context.Context, prID string) (model.PullReques
 		t := mergedAt.Time
 		p.MergedAt = &t
 	}
-
-	rows, err := r.DB.QueryContext(ctx, `SELECT user_id FROM pr_reviewers WHERE pull_request_id=$1 ORDER BY user_id`, prID)
-	if err != nil {
-		r.Log.Error("GetPR: query reviewers failed", zap.String("pr_id", prID), zap.Error(err))
-		return model.PullRequest{}, err
+	if closedAt.Valid {
+		t := closedAt.Time
+		p.ClosedAt = &t
 	}
 
-	defer func(rows *sql.Rows) {
-		err := rows.Close()
-		if err != nil {
-			r.Log.Error("GetPR: close rows failed", zap.String("pr_id", prID), zap.Error(err))
-		}
-	}(rows)
-
-	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
-			r.Log.Error("GetPR: scan reviewer failed", zap.String("pr_id", prID), zap.Error(err))
-			return model.PullRequest{}, err
-		}
-		p.Assigned = append(p.Assigned, id)
+	if err := r.loadReviewers(ctx, r.DB, &p); err != nil {
+		r.Log.Error("GetPR: load reviewers failed", zap.String("pr_id", prID), zap.Error(err))
+		return model.PullRequest{}, err
 	}
 
 	r.Log.Debug("GetPR: success", zap.String("pr_id", prID), zap.Int("reviewer_count", len(p.Assigned)))
@@ -107,9 +110,9 @@ func (r *Repositories) GetPR(ctx context.Context, prID string) (model.PullReques
 func (r *Repositories) GetPRForUpdate(ctx context.Context, tx *sql.Tx, prID string) (model.PullRequest, error) {
 	r.Log.Debug("GetPRForUpdate: start", zap.String("pr_id", prID))
 	var p model.PullRequest
-	var mergedAt sql.NullTime
-	if err := tx.QueryRowContext(ctx, `SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at FROM pull_requests WHERE pull_request_id=$1 FOR UPDATE`, prID).
-		Scan(&p.PullRequestID, &p.PullRequestName, &p.AuthorID, &p.Status, &p.CreatedAt, &mergedAt); err != nil {
+	var mergedAt, closedAt sql.NullTime
+	if err := tx.QueryRowContext(ctx, `SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, version, force_merged, changed_files, required_skills FROM pull_requests WHERE pull_request_id=$1 FOR UPDATE`, prID).
+		Scan(&p.PullRequestID, &p.PullRequestName, &p.AuthorID, &p.Status, &p.CreatedAt, &mergedAt, &closedAt, &p.Version, &p.ForceMerged, pq.Array(&p.ChangedFiles), pq.Array(&p.RequiredSkills)); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.Log.Debug("GetPRForUpdate: not found", zap.String("pr_id", prID))
 			return model.PullRequest{}, model.ErrNotFound
@@ -122,31 +125,71 @@ func (r *Repositories) GetPRForUpdate(ctx context.Context, tx *sql.Tx, prID stri
 		t := mergedAt.Time
 		p.MergedAt = &t
 	}
+	if closedAt.Valid {
+		t := closedAt.Time
+		p.ClosedAt = &t
+	}
 
-	rows, err := tx.QueryContext(ctx, `SELECT user_id FROM pr_reviewers WHERE pull_request_id=$1 ORDER BY user_id`, prID)
-	if err != nil {
-		r.Log.Error("GetPRForUpdate: query reviewers failed", zap.String("pr_id", prID), zap.Error(err))
+	if err := r.loadReviewers(ctx, tx, &p); err != nil {
+		r.Log.Error("GetPRForUpdate: load reviewers failed", zap.String("pr_id", prID), zap.Error(err))
 		return model.PullRequest{}, err
 	}
 
+	r.Log.Debug("GetPRForUpdate: success", zap.String("pr_id", prID), zap.Int("reviewer_count", len(p.Assigned)))
+	return p, nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// loadReviewers fills the assigned reviewers of p together with the latest review each of them
+// left since being assigned.
+func (r *Repositories) loadReviewers(ctx context.Context, q queryer, p *model.PullRequest) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT rv.user_id, rv.is_fallback, d.decision, d.comment, d.created_at
+		FROM pr_reviewers rv
+		LEFT JOIN LATERAL (
+			SELECT decision, comment, created_at
+			FROM pr_reviews v
+			WHERE v.pull_request_id = rv.pull_request_id AND v.user_id = rv.user_id AND v.created_at >= rv.assigned_at
+			ORDER BY v.created_at DESC, v.review_id DESC
+			LIMIT 1
+		) d ON true
+		WHERE rv.pull_request_id=$1
+		ORDER BY rv.user_id
+	`, p.PullRequestID)
+	if err != nil {
+		return err
+	}
+
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-			r.Log.Error("GetPRForUpdate: close rows failed", zap.Error(err))
+			r.Log.Error("loadReviewers: close rows failed", zap.Error(err))
 		}
 	}(rows)
 
 	for rows.Next() {
 		var id string
-		if err := rows.Scan(&id); err != nil {
-			r.Log.Error("GetPRForUpdate: scan reviewer failed", zap.String("pr_id", prID), zap.Error(err))
-			return model.PullRequest{}, err
+		var fallback bool
+		var decision, comment sql.NullString
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(&id, &fallback, &decision, &comment, &reviewedAt); err != nil {
+			return err
 		}
 		p.Assigned = append(p.Assigned, id)
+		if fallback {
+			p.Fallback = append(p.Fallback, id)
+		}
+		state := model.ReviewerState{UserID: id, State: model.ReviewPending}
+		if decision.Valid {
+			t := reviewedAt.Time
+			state.State, state.Comment, state.ReviewedAt = decision.String, comment.String, &t
+		}
+		p.Reviewers = append(p.Reviewers, state)
 	}
-
-	r.Log.Debug("GetPRForUpdate: success", zap.String("pr_id", prID), zap.Int("reviewer_count", len(p.Assigned)))
-	return p, nil
+	return rows.Err()
 }
 
 func (r *Repositories) SetPRMerged(ctx context.Context, tx *sql.Tx, prID string, mergedAt time.Time) error {
@@ -185,15 +228,315 @@ func (r *Repositories) RemoveReviewer(ctx context.Context, tx *sql.Tx, prID, use
 	return err
 }
 
-func (r *Repositories) AddReviewer(ctx context.Context, tx *sql.Tx, prID, userID string) error {
+func (r *Repositories) AddReviewer(ctx context.Context, tx *sql.Tx, prID, userID string, isFallback bool) error {
 	r.Log.Debug("AddReviewer: start", zap.String("pr_id", prID), zap.String("user", userID))
-	_, err := tx.ExecContext(ctx, `INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES($1,$2)`, prID, userID)
+	_, err := tx.ExecContext(ctx, `INSERT INTO pr_reviewers(pull_request_id, user_id, is_fallback) VALUES($1,$2,$3)`, prID, userID, isFallback)
 	if err != nil {
 		r.Log.Error("AddReviewer: insert failed", zap.Error(err))
 	}
 	return err
 }
 
+// ReplaceReviewer locks the PR, verifies it is still OPEN at the given version with
+// swap.OldUserID assigned and swap.NewUserID not yet assigned, swaps the reviewers,
+// bumps the version and returns the updated PR.
+func (r *Repositories) ReplaceReviewer(ctx context.Context, swap model.ReviewerSwap, version int64) (model.PullRequest, error) {
+	prID := swap.PullRequestID
+	r.Log.Debug("ReplaceReviewer: start", zap.String("pr_id", prID), zap.String("old", swap.OldUserID), zap.String("new", swap.NewUserID))
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		r.Log.Error("ReplaceReviewer: begin tx failed", zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.Log.Warn("ReplaceReviewer: rollback failed", zap.Error(err))
+		}
+	}()
+
+	pr, err := r.GetPRForUpdate(ctx, tx, prID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	if pr.Status == model.StatusMerged {
+		return model.PullRequest{}, model.ErrPRMerged
+	}
+	if pr.Status != model.StatusOpen {
+		return model.PullRequest{}, model.ErrPRNotOpen
+	}
+	if pr.Version != version {
+		r.Log.Info("ReplaceReviewer: version mismatch", zap.String("pr_id", prID), zap.Int64("expected", version), zap.Int64("actual", pr.Version))
+		return model.PullRequest{}, model.ErrConflict
+	}
+
+	assigned, err := r.IsReviewerAssigned(ctx, tx, prID, swap.OldUserID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	if !assigned {
+		return model.PullRequest{}, model.ErrNotAssigned
+	}
+	taken, err := r.IsReviewerAssigned(ctx, tx, prID, swap.NewUserID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	if taken {
+		return model.PullRequest{}, model.ErrConflict
+	}
+
+	if err := r.RemoveReviewer(ctx, tx, prID, swap.OldUserID); err != nil {
+		return model.PullRequest{}, err
+	}
+	if err := r.AddReviewer(ctx, tx, prID, swap.NewUserID, swap.IsFallback); err != nil {
+		return model.PullRequest{}, err
+	}
+	if err := r.appendEvent(ctx, tx, prID, model.EventReviewerReassigned, swapEventData(swap)); err != nil {
+		return model.PullRequest{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE pull_requests SET version = version + 1 WHERE pull_request_id=$1`, prID); err != nil {
+		r.Log.Error("ReplaceReviewer: bump version failed", zap.String("pr_id", prID), zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	updated, err := r.GetPRForUpdate(ctx, tx, prID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Log.Error("ReplaceReviewer: commit failed", zap.String("pr_id", prID), zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	r.Log.Info("ReplaceReviewer: success", zap.String("pr_id", prID), zap.String("old", swap.OldUserID), zap.String("new", swap.NewUserID))
+	return updated, nil
+}
+
+// AssignReviewer adds c.UserID as a reviewer of an OPEN PR at c.Version and bumps the version.
+func (r *Repositories) AssignReviewer(ctx context.Context, c model.ReviewerChange) (model.PullRequest, error) {
+	return r.changeReviewer(ctx, "AssignReviewer", model.EventReviewerAdded, c, func(tx *sql.Tx, pr model.PullRequest) error {
+		if contains(pr.Assigned, c.UserID) {
+			return model.ErrAlreadyAssigned
+		}
+		return r.AddReviewer(ctx, tx, c.PullRequestID, c.UserID, c.IsFallback)
+	})
+}
+
+// UnassignReviewer removes c.UserID from an OPEN PR at c.Version without a replacement
+// as long as at least c.MinReviewers stay assigned, and bumps the version.
+func (r *Repositories) UnassignReviewer(ctx context.Context, c model.ReviewerChange) (model.PullRequest, error) {
+	return r.changeReviewer(ctx, "UnassignReviewer", model.EventReviewerRemoved, c, func(tx *sql.Tx, pr model.PullRequest) error {
+		if !contains(pr.Assigned, c.UserID) {
+			return model.ErrNotAssigned
+		}
+		if len(pr.Assigned)-1 < c.MinReviewers {
+			return model.ErrNotEnoughReviewers
+		}
+		return r.RemoveReviewer(ctx, tx, c.PullRequestID, c.UserID)
+	})
+}
+
+// changeReviewer runs apply on the locked PR once it is verified to be OPEN at c.Version,
+// records eventType, bumps the version and returns the updated PR.
+func (r *Repositories) changeReviewer(ctx context.Context, op, eventType string, c model.ReviewerChange, apply func(tx *sql.Tx, pr model.PullRequest) error) (model.PullRequest, error) {
+	prID := c.PullRequestID
+	r.Log.Debug(op+": start", zap.String("pr_id", prID), zap.String("user", c.UserID))
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		r.Log.Error(op+": begin tx failed", zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.Log.Warn(op+": rollback failed", zap.Error(err))
+		}
+	}()
+
+	pr, err := r.GetPRForUpdate(ctx, tx, prID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	if pr.Status == model.StatusMerged {
+		return model.PullRequest{}, model.ErrPRMerged
+	}
+	if pr.Status != model.StatusOpen {
+		return model.PullRequest{}, model.ErrPRNotOpen
+	}
+	if pr.Version != c.Version {
+		r.Log.Info(op+": version mismatch", zap.String("pr_id", prID), zap.Int64("expected", c.Version), zap.Int64("actual", pr.Version))
+		return model.PullRequest{}, model.ErrConflict
+	}
+
+	if err := apply(tx, pr); err != nil {
+		return model.PullRequest{}, err
+	}
+	data := map[string]any{"user_id": c.UserID}
+	if eventType == model.EventReviewerAdded {
+		data["fallback"] = c.IsFallback
+	}
+	if err := r.appendEvent(ctx, tx, prID, eventType, data); err != nil {
+		return model.PullRequest{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE pull_requests SET version = version + 1 WHERE pull_request_id=$1`, prID); err != nil {
+		r.Log.Error(op+": bump version failed", zap.String("pr_id", prID), zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	updated, err := r.GetPRForUpdate(ctx, tx, prID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Log.Error(op+": commit failed", zap.String("pr_id", prID), zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	r.Log.Info(op+": success", zap.String("pr_id", prID), zap.String("user", c.UserID))
+	return updated, nil
+}
+
+// TransitionPR changes the PR status if it is still in one of t.From, optionally replacing its reviewers,
+// and bumps the version. A PR in another status or at another version yields model.ErrConflict.
+func (r *Repositories) TransitionPR(ctx context.Context, t model.PRTransition) (model.PullRequest, error) {
+	prID := t.PullRequestID
+	r.Log.Debug("TransitionPR: start", zap.String("pr_id", prID), zap.String("to", t.To))
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		r.Log.Error("TransitionPR: begin tx failed", zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.Log.Warn("TransitionPR: rollback failed", zap.Error(err))
+		}
+	}()
+
+	pr, err := r.GetPRForUpdate(ctx, tx, prID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	if !contains(t.From, pr.Status) || (t.Version != 0 && pr.Version != t.Version) {
+		r.Log.Info("TransitionPR: state changed", zap.String("pr_id", prID), zap.String("status", pr.Status), zap.Int64("version", pr.Version))
+		return model.PullRequest{}, model.ErrConflict
+	}
+
+	if t.ReleaseReviewers {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id=$1`, prID); err != nil {
+			r.Log.Error("TransitionPR: release reviewers failed", zap.String("pr_id", prID), zap.Error(err))
+			return model.PullRequest{}, err
+		}
+	}
+	for _, u := range t.Assigned {
+		if err := r.AddReviewer(ctx, tx, prID, u, contains(t.Fallback, u)); err != nil {
+			return model.PullRequest{}, err
+		}
+	}
+	data := map[string]any{"from": pr.Status, "to": t.To}
+	if t.ReleaseReviewers && len(pr.Assigned) > 0 {
+		data["released_reviewers"] = pr.Assigned
+	}
+	if err := r.appendEvent(ctx, tx, prID, model.EventStatusChanged, data); err != nil {
+		return model.PullRequest{}, err
+	}
+	if len(t.Assigned) > 0 {
+		if err := r.appendEvent(ctx, tx, prID, model.EventReviewersAssigned, assignmentEventData(t.Assigned, t.Fallback, t.Assignment)); err != nil {
+			return model.PullRequest{}, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE pull_requests
+		SET status=$2, closed_at=CASE WHEN $2 = 'CLOSED' THEN now() END, version=version+1
+		WHERE pull_request_id=$1
+	`, prID, t.To); err != nil {
+		r.Log.Error("TransitionPR: update status failed", zap.String("pr_id", prID), zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	updated, err := r.GetPRForUpdate(ctx, tx, prID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Log.Error("TransitionPR: commit failed", zap.String("pr_id", prID), zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	r.Log.Info("TransitionPR: success", zap.String("pr_id", prID), zap.String("from", pr.Status), zap.String("to", t.To))
+	return updated, nil
+}
+
+// AddReview records a decision of an assigned reviewer on an OPEN PR and bumps the PR version.
+// A non-zero ifVersion must match the locked PR, otherwise model.ErrConflict is returned.
+func (r *Repositories) AddReview(ctx context.Context, review model.Review, ifVersion int64) (model.PullRequest, error) {
+	prID := review.PullRequestID
+	r.Log.Debug("AddReview: start", zap.String("pr_id", prID), zap.String("user", review.UserID), zap.String("decision", review.Decision))
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		r.Log.Error("AddReview: begin tx failed", zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.Log.Warn("AddReview: rollback failed", zap.Error(err))
+		}
+	}()
+
+	pr, err := r.GetPRForUpdate(ctx, tx, prID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+	if pr.Status == model.StatusMerged {
+		return model.PullRequest{}, model.ErrPRMerged
+	}
+	if pr.Status != model.StatusOpen {
+		return model.PullRequest{}, model.ErrPRNotOpen
+	}
+	if ifVersion != 0 && pr.Version != ifVersion {
+		return model.PullRequest{}, model.ErrConflict
+	}
+	if !contains(pr.Assigned, review.UserID) {
+		return model.PullRequest{}, model.ErrNotAssigned
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO pr_reviews(pull_request_id, user_id, decision, comment) VALUES($1,$2,$3,$4)`,
+		prID, review.UserID, review.Decision, review.Comment); err != nil {
+		r.Log.Error("AddReview: insert failed", zap.String("pr_id", prID), zap.Error(err))
+		return model.PullRequest{}, err
+	}
+	if err := r.appendEvent(ctx, tx, prID, model.EventReviewed, map[string]any{
+		"user_id":  review.UserID,
+		"decision": review.Decision,
+		"comment":  review.Comment,
+	}); err != nil {
+		return model.PullRequest{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE pull_requests SET version = version + 1 WHERE pull_request_id=$1`, prID); err != nil {
+		r.Log.Error("AddReview: bump version failed", zap.String("pr_id", prID), zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	updated, err := r.GetPRForUpdate(ctx, tx, prID)
+	if err != nil {
+		return model.PullRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Log.Error("AddReview: commit failed", zap.String("pr_id", prID), zap.Error(err))
+		return model.PullRequest{}, err
+	}
+
+	r.Log.Info("AddReview: success", zap.String("pr_id", prID), zap.String("user", review.UserID), zap.String("decision", review.Decision))
+	return updated, nil
+}
+
 func (r *Repositories) GetAssignedPRsForUser(ctx context.Context, userID string) ([]model.PullRequestShort, error) {
 	r.Log.Debug("GetAssignedPRsForUser: start", zap.String("user", userID))
 	rows, err := r.DB.QueryContext(ctx, `
@@ -229,6 +572,184 @@ func (r *Repositories) GetAssignedPRsForUser(ctx context.Context, userID string)
 	return out, nil
 }
 
+func (r *Repositories) GetOpenPRsForReviewers(ctx context.Context, userIDs []string) ([]model.PullRequest, error) {
+	r.Log.Debug("GetOpenPRsForReviewers: start", zap.Int("users", len(userIDs)))
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status, p.created_at, p.version, p.required_skills, r.user_id, r.is_fallback
+		FROM pull_requests p
+		JOIN pr_reviewers r ON r.pull_request_id = p.pull_request_id
+		WHERE p.status = 'OPEN' AND p.pull_request_id IN (
+			SELECT pull_request_id FROM pr_reviewers WHERE user_id = ANY($1)
+		)
+		ORDER BY p.created_at, p.pull_request_id, r.user_id
+	`, pq.Array(userIDs))
+	if err != nil {
+		r.Log.Error("GetOpenPRsForReviewers: query failed", zap.Error(err))
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.Log.Error("GetOpenPRsForReviewers: close rows failed", zap.Error(err))
+		}
+	}(rows)
+
+	var out []model.PullRequest
+	for rows.Next() {
+		var p model.PullRequest
+		var reviewer string
+		var fallback bool
+		if err := rows.Scan(&p.PullRequestID, &p.PullRequestName, &p.AuthorID, &p.Status, &p.CreatedAt, &p.Version, pq.Array(&p.RequiredSkills), &reviewer, &fallback); err != nil {
+			r.Log.Error("GetOpenPRsForReviewers: scan failed", zap.Error(err))
+			return nil, err
+		}
+		if n := len(out); n == 0 || out[n-1].PullRequestID != p.PullRequestID {
+			out = append(out, p)
+		}
+		last := &out[len(out)-1]
+		last.Assigned = append(last.Assigned, reviewer)
+		if fallback {
+			last.Fallback = append(last.Fallback, reviewer)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("GetOpenPRsForReviewers: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("GetOpenPRsForReviewers: success", zap.Int("count", len(out)))
+	return out, nil
+}
+
+// DeactivateUsersAndReassign deactivates team members and applies reviewer swaps in one transaction.
+// It fails with model.ErrConflict if any affected PR is no longer OPEN, was changed since the swaps
+// were planned (its version differs from ReviewerSwap.Version) or a swap no longer matches.
+func (r *Repositories) DeactivateUsersAndReassign(ctx context.Context, teamName string, userIDs []string, swaps []model.ReviewerSwap) error {
+	r.Log.Debug("DeactivateUsersAndReassign: start", zap.String("team", teamName), zap.Int("users", len(userIDs)), zap.Int("swaps", len(swaps)))
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		r.Log.Error("DeactivateUsersAndReassign: begin tx failed", zap.Error(err))
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.Log.Warn("DeactivateUsersAndReassign: rollback failed", zap.Error(err))
+		}
+	}()
+
+	prIDs := make([]string, 0, len(swaps))
+	oldIDs := make([]string, 0, len(swaps))
+	newIDs := make([]string, 0, len(swaps))
+	fallbacks := make([]bool, 0, len(swaps))
+	planned := make(map[string]int64, len(swaps))
+	var lockIDs []string
+	for _, sw := range swaps {
+		prIDs = append(prIDs, sw.PullRequestID)
+		oldIDs = append(oldIDs, sw.OldUserID)
+		newIDs = append(newIDs, sw.NewUserID)
+		fallbacks = append(fallbacks, sw.IsFallback)
+		if _, ok := planned[sw.PullRequestID]; !ok {
+			planned[sw.PullRequestID] = sw.Version
+			lockIDs = append(lockIDs, sw.PullRequestID)
+		}
+	}
+
+	if len(lockIDs) > 0 {
+		versions, err := r.lockOpenPRVersions(ctx, tx, lockIDs)
+		if err != nil {
+			r.Log.Error("DeactivateUsersAndReassign: lock PRs failed", zap.Error(err))
+			return err
+		}
+		if len(versions) != len(lockIDs) {
+			r.Log.Info("DeactivateUsersAndReassign: PR state changed", zap.Int("expected", len(lockIDs)), zap.Int("locked", len(versions)))
+			return model.ErrConflict
+		}
+		for id, v := range versions {
+			if v != planned[id] {
+				r.Log.Info("DeactivateUsersAndReassign: version mismatch", zap.String("pr_id", id), zap.Int64("expected", planned[id]), zap.Int64("actual", v))
+				return model.ErrConflict
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE pull_requests SET version = version + 1 WHERE pull_request_id = ANY($1)`, pq.Array(lockIDs)); err != nil {
+			r.Log.Error("DeactivateUsersAndReassign: bump versions failed", zap.Error(err))
+			return err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE users SET is_active=false WHERE team_name=$1 AND user_id = ANY($2)`, teamName, pq.Array(userIDs))
+	if err != nil {
+		r.Log.Error("DeactivateUsersAndReassign: deactivate failed", zap.Error(err))
+		return err
+	}
+	if n, _ := res.RowsAffected(); int(n) != len(userIDs) {
+		r.Log.Info("DeactivateUsersAndReassign: team members changed", zap.Int64("updated", n))
+		return model.ErrConflict
+	}
+
+	if len(swaps) > 0 {
+		res, err = tx.ExecContext(ctx, `
+			UPDATE pr_reviewers r
+			SET user_id = s.new_user, is_fallback = s.is_fallback, assigned_at = now()
+			FROM unnest($1::text[], $2::text[], $3::text[], $4::boolean[]) AS s(pr_id, old_user, new_user, is_fallback)
+			WHERE r.pull_request_id = s.pr_id AND r.user_id = s.old_user
+		`, pq.Array(prIDs), pq.Array(oldIDs), pq.Array(newIDs), pq.Array(fallbacks))
+		if err != nil {
+			r.Log.Error("DeactivateUsersAndReassign: swap reviewers failed", zap.Error(err))
+			return err
+		}
+		if n, _ := res.RowsAffected(); int(n) != len(swaps) {
+			r.Log.Info("DeactivateUsersAndReassign: reviewers changed", zap.Int64("updated", n), zap.Int("expected", len(swaps)))
+			return model.ErrConflict
+		}
+	}
+	for _, sw := range swaps {
+		data := swapEventData(sw)
+		data["reason"] = "deactivated"
+		if err := r.appendEvent(ctx, tx, sw.PullRequestID, model.EventReviewerReassigned, data); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Log.Error("DeactivateUsersAndReassign: commit failed", zap.Error(err))
+		return err
+	}
+
+	r.Log.Info("DeactivateUsersAndReassign: success", zap.String("team", teamName), zap.Int("users", len(userIDs)), zap.Int("swaps", len(swaps)))
+	return nil
+}
+
+// lockOpenPRVersions locks the OPEN PRs among prIDs and returns their current versions.
+func (r *Repositories) lockOpenPRVersions(ctx context.Context, tx *sql.Tx, prIDs []string) (map[string]int64, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT pull_request_id, version FROM pull_requests WHERE pull_request_id = ANY($1) AND status = 'OPEN' FOR UPDATE`,
+		pq.Array(prIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.Log.Error("lockOpenPRVersions: close rows failed", zap.Error(err))
+		}
+	}(rows)
+
+	versions := make(map[string]int64, len(prIDs))
+	for rows.Next() {
+		var id string
+		var v int64
+		if err := rows.Scan(&id, &v); err != nil {
+			return nil, err
+		}
+		versions[id] = v
+	}
+	return versions, rows.Err()
+}
+
+// UpdatePR saves PR fields if the stored version still equals pr.Version and bumps it.
+// A lost update is reported as model.ErrConflict.
 func (r *Repositories) UpdatePR(ctx context.Context, pr model.PullRequest) error {
 	r.Log.Debug("UpdatePR: start", zap.String("pr_id", pr.PullRequestID))
 	var err error
@@ -244,17 +765,26 @@ func (r *Repositories) UpdatePR(ctx context.Context, pr model.PullRequest) error
 		}
 	}()
 
-	_, err = tx.ExecContext(ctx,
+	res, err := tx.ExecContext(ctx,
 		`UPDATE pull_requests 
-		 SET pull_request_name=$1, status=$2, merged_at=$3 
-		 WHERE pull_request_id=$4`,
-		pr.PullRequestName, pr.Status, pr.MergedAt, pr.PullRequestID,
+		 SET pull_request_name=$1, status=$2, merged_at=$3, force_merged=$6, version=version+1 
+		 WHERE pull_request_id=$4 AND version=$5`,
+		pr.PullRequestName, pr.Status, pr.MergedAt, pr.PullRequestID, pr.Version, pr.ForceMerged,
 	)
 
 	if err != nil {
 		r.Log.Error("UpdatePR: update failed", zap.String("pr_id", pr.PullRequestID), zap.Error(err))
 		return err
 	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		r.Log.Info("UpdatePR: version mismatch", zap.String("pr_id", pr.PullRequestID), zap.Int64("version", pr.Version))
+		return model.ErrConflict
+	}
+	if pr.Status == model.StatusMerged {
+		if err := r.appendEvent(ctx, tx, pr.PullRequestID, model.EventMerged, map[string]any{"force": pr.ForceMerged}); err != nil {
+			return err
+		}
+	}
 
 	if err := tx.Commit(); err != nil {
 		r.Log.Error("UpdatePR: commit failed", zap.String("pr_id", pr.PullRequestID), zap.Error(err))
@@ -263,3 +793,12 @@ func (r *Repositories) UpdatePR(ctx context.Context, pr model.PullRequest) error
 	r.Log.Info("UpdatePR: success", zap.String("pr_id", pr.PullRequestID))
 	return nil
 }
+
+func contains(items []string, v string) bool {
+	for _, it := range items {
+		if it == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// GetUserSkills returns the skill tags of each of userIDs, sorted. Users without skills are left out.
+func (r *Repositories) GetUserSkills(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	r.Log.Debug("GetUserSkills: start", zap.Int("users", len(userIDs)))
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT user_id, skill FROM user_skills WHERE user_id = ANY($1) ORDER BY user_id, skill`, pq.Array(userIDs))
+	if err != nil {
+		r.Log.Error("GetUserSkills: query failed", zap.Error(err))
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.Log.Error("GetUserSkills: close rows failed", zap.Error(err))
+		}
+	}()
+
+	skills := make(map[string][]string, len(userIDs))
+	for rows.Next() {
+		var userID, skill string
+		if err := rows.Scan(&userID, &skill); err != nil {
+			r.Log.Error("GetUserSkills: scan failed", zap.Error(err))
+			return nil, err
+		}
+		skills[userID] = append(skills[userID], skill)
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("GetUserSkills: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("GetUserSkills: success", zap.Int("items", len(skills)))
+	return skills, nil
+}
+
+// SetUserSkills replaces the skill tags of a user.
+func (r *Repositories) SetUserSkills(ctx context.Context, userID string, skills []string) error {
+	r.Log.Debug("SetUserSkills: start", zap.String("user", userID), zap.Int("skills", len(skills)))
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		r.Log.Error("SetUserSkills: begin tx failed", zap.Error(err))
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.Log.Warn("SetUserSkills: rollback failed", zap.Error(err))
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_skills WHERE user_id=$1`, userID); err != nil {
+		r.Log.Error("SetUserSkills: delete failed", zap.String("user", userID), zap.Error(err))
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO user_skills(user_id, skill) SELECT $1, unnest($2::TEXT[])`, userID, pq.Array(skills)); err != nil {
+		r.Log.Error("SetUserSkills: insert failed", zap.String("user", userID), zap.Error(err))
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Log.Error("SetUserSkills: commit failed", zap.Error(err))
+		return err
+	}
+	r.Log.Info("SetUserSkills: success", zap.String("user", userID), zap.Int("skills", len(skills)))
+	return nil
+}
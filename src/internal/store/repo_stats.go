@@ -3,7 +3,9 @@ package store
 import (
 	"context"
 	"database/sql"
+	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
 
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -65,3 +67,122 @@ func (r *Repositories) GetPRReviewStats(ctx context.Context) (map[string]int, er
 		return prID, nil
 	}, "GetPRReviewStats")
 }
+
+func (r *Repositories) GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error) {
+	r.Log.Debug("GetOpenReviewCounts: start", zap.Int("users", len(userIDs)))
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT r.user_id, COUNT(*)
+		FROM pr_reviewers r
+		JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+		WHERE p.status = 'OPEN' AND r.user_id = ANY($1)
+		GROUP BY r.user_id
+	`, pq.Array(userIDs))
+	if err != nil {
+		r.Log.Error("GetOpenReviewCounts: query failed", zap.Error(err))
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.Log.Error("GetOpenReviewCounts: close rows failed", zap.Error(err))
+		}
+	}()
+
+	counts := make(map[string]int, len(userIDs))
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			r.Log.Error("GetOpenReviewCounts: scan failed", zap.Error(err))
+			return nil, err
+		}
+		counts[userID] = count
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("GetOpenReviewCounts: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("GetOpenReviewCounts: success", zap.Int("items", len(counts)))
+	return counts, nil
+}
+
+// GetRecentPairCounts counts, per reviewer, the PRs among the latest window PRs of authorID they were assigned to.
+// The PR excludePRID is left out of the window.
+func (r *Repositories) GetRecentPairCounts(ctx context.Context, authorID, excludePRID string, window int) (map[string]int, error) {
+	r.Log.Debug("GetRecentPairCounts: start", zap.String("author", authorID), zap.Int("window", window))
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT rv.user_id, COUNT(*)
+		FROM (
+		    SELECT pull_request_id FROM pull_requests
+		    WHERE author_id = $1 AND pull_request_id <> $2
+		    ORDER BY created_at DESC, pull_request_id DESC
+		    LIMIT $3
+		) recent
+		JOIN pr_reviewers rv ON rv.pull_request_id = recent.pull_request_id
+		GROUP BY rv.user_id
+	`, authorID, excludePRID, window)
+	if err != nil {
+		r.Log.Error("GetRecentPairCounts: query failed", zap.Error(err))
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.Log.Error("GetRecentPairCounts: close rows failed", zap.Error(err))
+		}
+	}()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			r.Log.Error("GetRecentPairCounts: scan failed", zap.Error(err))
+			return nil, err
+		}
+		counts[userID] = count
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("GetRecentPairCounts: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("GetRecentPairCounts: success", zap.Int("items", len(counts)))
+	return counts, nil
+}
+
+// GetTeamPairCounts counts how often each reviewer was assigned to PRs authored by members of teamName.
+func (r *Repositories) GetTeamPairCounts(ctx context.Context, teamName string) ([]model.PairCount, error) {
+	r.Log.Debug("GetTeamPairCounts: start", zap.String("team", teamName))
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT p.author_id, rv.user_id, COUNT(*)
+		FROM pull_requests p
+		JOIN users u ON u.user_id = p.author_id
+		JOIN pr_reviewers rv ON rv.pull_request_id = p.pull_request_id
+		WHERE u.team_name = $1
+		GROUP BY p.author_id, rv.user_id
+		ORDER BY p.author_id, rv.user_id
+	`, teamName)
+	if err != nil {
+		r.Log.Error("GetTeamPairCounts: query failed", zap.Error(err))
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.Log.Error("GetTeamPairCounts: close rows failed", zap.Error(err))
+		}
+	}()
+
+	var pairs []model.PairCount
+	for rows.Next() {
+		var pc model.PairCount
+		if err := rows.Scan(&pc.AuthorID, &pc.ReviewerID, &pc.Count); err != nil {
+			r.Log.Error("GetTeamPairCounts: scan failed", zap.Error(err))
+			return nil, err
+		}
+		pairs = append(pairs, pc)
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("GetTeamPairCounts: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("GetTeamPairCounts: success", zap.Int("pairs", len(pairs)))
+	return pairs, nil
+}
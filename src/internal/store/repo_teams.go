@@ -63,8 +63,8 @@ func (r *Repositories) CreateTeam(ctx context.Context, t model.Team) (model.Team
 
 	for _, m := range t.Members {
 		if _, err := tx.ExecContext(ctx,
-			`INSERT INTO users(user_id, username, team_name, is_active) VALUES($1,$2,$3,$4)`,
-			m.UserID, m.Username, t.TeamName, m.IsActive); err != nil {
+			`INSERT INTO users(user_id, username, team_name, is_active, role) VALUES($1,$2,$3,$4,$5)`,
+			m.UserID, m.Username, t.TeamName, m.IsActive, m.Role); err != nil {
 			r.Log.Error("TeamRepo.CreateTeam: insert user failed", zap.String("user", m.UserID), zap.Error(err))
 			return model.Team{}, err
 		}
@@ -85,7 +85,7 @@ func (r *Repositories) GetTeam(ctx context.Context, teamName string) (model.Team
 	var t model.Team
 	t.TeamName = teamName
 
-	rows, err := r.Teams.db.QueryContext(ctx, `SELECT user_id, username, is_active FROM users WHERE team_name=$1`, teamName)
+	rows, err := r.Teams.db.QueryContext(ctx, `SELECT user_id, username, is_active, role FROM users WHERE team_name=$1`, teamName)
 	if err != nil {
 		r.Log.Error("TeamRepo.GetTeam: query failed", zap.Error(err))
 		return model.Team{}, err
@@ -100,7 +100,7 @@ func (r *Repositories) GetTeam(ctx context.Context, teamName string) (model.Team
 
 	for rows.Next() {
 		var m model.TeamMember
-		if err := rows.Scan(&m.UserID, &m.Username, &m.IsActive); err != nil {
+		if err := rows.Scan(&m.UserID, &m.Username, &m.IsActive, &m.Role); err != nil {
 			r.Log.Error("TeamRepo.GetTeam: scan failed", zap.Error(err))
 			return model.Team{}, err
 		}
@@ -120,3 +120,101 @@ func (r *Repositories) GetTeam(ctx context.Context, teamName string) (model.Team
 	r.Log.Debug("TeamRepo.GetTeam: success", zap.String("team", teamName), zap.Int("members", len(t.Members)))
 	return t, nil
 }
+
+func (r *Repositories) GetTeamSettings(ctx context.Context, teamName string) (model.TeamSettings, error) {
+	r.Log.Debug("TeamRepo.GetTeamSettings: start", zap.String("team", teamName))
+	var s model.TeamSettings
+	if err := r.Teams.db.QueryRowContext(ctx,
+		`SELECT team_name, reviewers_count, min_reviewers, strategy, required_approvals, anti_affinity_window,
+		        min_senior_reviewers, senior_policy
+		 FROM team_settings WHERE team_name=$1`, teamName).
+		Scan(&s.TeamName, &s.ReviewersCount, &s.MinReviewers, &s.Strategy, &s.RequiredApprovals, &s.AntiAffinityWindow,
+			&s.MinSeniorReviewers, &s.SeniorPolicy); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.Log.Debug("TeamRepo.GetTeamSettings: not found", zap.String("team", teamName))
+			return model.TeamSettings{}, model.ErrNotFound
+		}
+		r.Log.Error("TeamRepo.GetTeamSettings: query failed", zap.Error(err))
+		return model.TeamSettings{}, err
+	}
+
+	rows, err := r.Teams.db.QueryContext(ctx, `SELECT fallback_team FROM team_fallbacks WHERE team_name=$1 ORDER BY position`, teamName)
+	if err != nil {
+		r.Log.Error("TeamRepo.GetTeamSettings: query fallbacks failed", zap.Error(err))
+		return model.TeamSettings{}, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.Log.Error("TeamRepo.GetTeamSettings: close rows failed", zap.Error(err))
+		}
+	}(rows)
+
+	for rows.Next() {
+		var fallback string
+		if err := rows.Scan(&fallback); err != nil {
+			r.Log.Error("TeamRepo.GetTeamSettings: scan fallback failed", zap.Error(err))
+			return model.TeamSettings{}, err
+		}
+		s.FallbackTeams = append(s.FallbackTeams, fallback)
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("TeamRepo.GetTeamSettings: rows error", zap.Error(err))
+		return model.TeamSettings{}, err
+	}
+
+	r.Log.Debug("TeamRepo.GetTeamSettings: success", zap.String("team", teamName), zap.Int("fallbacks", len(s.FallbackTeams)))
+	return s, nil
+}
+
+func (r *Repositories) UpsertTeamSettings(ctx context.Context, settings model.TeamSettings) (model.TeamSettings, error) {
+	r.Log.Debug("TeamRepo.UpsertTeamSettings: start", zap.String("team", settings.TeamName))
+	tx, err := r.Teams.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		r.Log.Error("TeamRepo.UpsertTeamSettings: begin tx failed", zap.Error(err))
+		return model.TeamSettings{}, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.Log.Warn("TeamRepo.UpsertTeamSettings: rollback failed", zap.Error(err))
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO team_settings(team_name, reviewers_count, min_reviewers, strategy, required_approvals, anti_affinity_window,
+		                          min_senior_reviewers, senior_policy)
+		VALUES($1,$2,$3,$4,$5,$6,$7,$8)
+		ON CONFLICT (team_name) DO UPDATE
+		SET reviewers_count=EXCLUDED.reviewers_count, min_reviewers=EXCLUDED.min_reviewers, strategy=EXCLUDED.strategy,
+		    required_approvals=EXCLUDED.required_approvals, anti_affinity_window=EXCLUDED.anti_affinity_window,
+		    min_senior_reviewers=EXCLUDED.min_senior_reviewers, senior_policy=EXCLUDED.senior_policy
+	`, settings.TeamName, settings.ReviewersCount, settings.MinReviewers, settings.Strategy, settings.RequiredApprovals,
+		settings.AntiAffinityWindow, settings.MinSeniorReviewers, settings.SeniorPolicy); err != nil {
+		r.Log.Error("TeamRepo.UpsertTeamSettings: upsert failed", zap.Error(err))
+		return model.TeamSettings{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM team_fallbacks WHERE team_name=$1`, settings.TeamName); err != nil {
+		r.Log.Error("TeamRepo.UpsertTeamSettings: delete fallbacks failed", zap.Error(err))
+		return model.TeamSettings{}, err
+	}
+
+	for i, fallback := range settings.FallbackTeams {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO team_fallbacks(team_name, fallback_team, position) VALUES($1,$2,$3)`,
+			settings.TeamName, fallback, i); err != nil {
+			r.Log.Error("TeamRepo.UpsertTeamSettings: insert fallback failed", zap.String("fallback", fallback), zap.Error(err))
+			return model.TeamSettings{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.Log.Error("TeamRepo.UpsertTeamSettings: commit failed", zap.Error(err))
+		return model.TeamSettings{}, err
+	}
+
+	r.Log.Info("TeamRepo.UpsertTeamSettings: success", zap.String("team", settings.TeamName), zap.Int("fallbacks", len(settings.FallbackTeams)))
+	return settings, nil
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"github.com/ce-fello/pr-reviewer-service/src/internal/model"
 
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -30,9 +31,8 @@ func (r *Repositories) SetUserIsActive(ctx context.Context, userID string, isAct
 		r.Log.Debug("SetUserIsActive: user not found", zap.String("user", userID))
 		return model.User{}, model.ErrNotFound
 	}
-	var u model.User
-	if err := r.DB.QueryRowContext(ctx, `SELECT user_id, username, team_name, is_active FROM users WHERE user_id=$1`, userID).
-		Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
+	u, err := r.GetUser(ctx, userID)
+	if err != nil {
 		r.Log.Error("SetUserIsActive: fetch user failed", zap.Error(err))
 		return model.User{}, err
 	}
@@ -43,8 +43,9 @@ func (r *Repositories) SetUserIsActive(ctx context.Context, userID string, isAct
 func (r *Repositories) GetUser(ctx context.Context, userID string) (model.User, error) {
 	r.Log.Debug("GetUser: start", zap.String("user", userID))
 	var u model.User
-	if err := r.DB.QueryRowContext(ctx, `SELECT user_id, username, team_name, is_active FROM users WHERE user_id=$1`, userID).
-		Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
+	var maxOpen sql.NullInt64
+	if err := r.DB.QueryRowContext(ctx, `SELECT user_id, username, team_name, is_active, role, max_open_reviews FROM users WHERE user_id=$1`, userID).
+		Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.Role, &maxOpen); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.Log.Debug("GetUser: not found", zap.String("user", userID))
 			return model.User{}, model.ErrNotFound
@@ -52,13 +53,28 @@ func (r *Repositories) GetUser(ctx context.Context, userID string) (model.User,
 		r.Log.Error("GetUser: query failed", zap.Error(err))
 		return model.User{}, err
 	}
+	if maxOpen.Valid {
+		n := int(maxOpen.Int64)
+		u.MaxOpenReviews = &n
+	}
 	r.Log.Debug("GetUser: success", zap.String("user", userID))
 	return u, nil
 }
 
+// GetActiveTeamMembersExcept lists the active, present members of a team ordered by user_id,
+// a stable order is what lets a recorded seed replay a pick.
 func (r *Repositories) GetActiveTeamMembersExcept(ctx context.Context, teamName string, excludeUserID string) ([]string, error) {
 	r.Log.Debug("GetActiveTeamMembersExcept: start", zap.String("team", teamName), zap.String("exclude", excludeUserID))
-	rows, err := r.DB.QueryContext(ctx, `SELECT user_id FROM users WHERE team_name=$1 AND is_active=true AND user_id <> $2`, teamName, excludeUserID)
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT u.user_id
+		FROM users u
+		WHERE u.team_name=$1 AND u.is_active=true AND u.user_id <> $2
+		  AND NOT EXISTS (
+		      SELECT 1 FROM user_absences a
+		      WHERE a.user_id = u.user_id AND a.starts_at <= now() AND a.ends_at > now()
+		  )
+		ORDER BY u.user_id
+	`, teamName, excludeUserID)
 	if err != nil {
 		r.Log.Error("GetActiveTeamMembersExcept: query failed", zap.Error(err))
 		return nil, err
@@ -81,3 +97,158 @@ func (r *Repositories) GetActiveTeamMembersExcept(ctx context.Context, teamName
 	r.Log.Debug("GetActiveTeamMembersExcept: success", zap.Int("count", len(users)))
 	return users, nil
 }
+
+// SetUserMaxOpenReviews sets the open review limit of a user, nil removes the limit.
+func (r *Repositories) SetUserMaxOpenReviews(ctx context.Context, userID string, maxOpen *int) (model.User, error) {
+	r.Log.Debug("SetUserMaxOpenReviews: start", zap.String("user", userID))
+	res, err := r.DB.ExecContext(ctx, `UPDATE users SET max_open_reviews=$2 WHERE user_id=$1`, userID, maxOpen)
+	if err != nil {
+		r.Log.Error("SetUserMaxOpenReviews: update failed", zap.Error(err))
+		return model.User{}, err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		r.Log.Debug("SetUserMaxOpenReviews: user not found", zap.String("user", userID))
+		return model.User{}, model.ErrNotFound
+	}
+	u, err := r.GetUser(ctx, userID)
+	if err != nil {
+		r.Log.Error("SetUserMaxOpenReviews: fetch user failed", zap.Error(err))
+		return model.User{}, err
+	}
+	r.Log.Info("SetUserMaxOpenReviews: success", zap.String("user", userID))
+	return u, nil
+}
+
+// GetUsersAtCapacity returns those of userIDs who already review as many OPEN PRs as their max_open_reviews allows.
+func (r *Repositories) GetUsersAtCapacity(ctx context.Context, userIDs []string) ([]string, error) {
+	r.Log.Debug("GetUsersAtCapacity: start", zap.Int("users", len(userIDs)))
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT u.user_id
+		FROM users u
+		WHERE u.user_id = ANY($1) AND u.max_open_reviews IS NOT NULL
+		  AND u.max_open_reviews <= (
+		      SELECT COUNT(*) FROM pr_reviewers rv
+		      JOIN pull_requests p ON p.pull_request_id = rv.pull_request_id
+		      WHERE rv.user_id = u.user_id AND p.status = 'OPEN'
+		  )
+		ORDER BY u.user_id
+	`, pq.Array(userIDs))
+	if err != nil {
+		r.Log.Error("GetUsersAtCapacity: query failed", zap.Error(err))
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.Log.Error("GetUsersAtCapacity: close rows failed", zap.Error(err))
+		}
+	}(rows)
+
+	var full []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			r.Log.Error("GetUsersAtCapacity: scan failed", zap.Error(err))
+			return nil, err
+		}
+		full = append(full, id)
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("GetUsersAtCapacity: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("GetUsersAtCapacity: success", zap.Int("count", len(full)))
+	return full, nil
+}
+
+// GetMaxOpenReviews returns the max_open_reviews of those of userIDs who have a limit.
+func (r *Repositories) GetMaxOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	r.Log.Debug("GetMaxOpenReviews: start", zap.Int("users", len(userIDs)))
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT user_id, max_open_reviews
+		FROM users
+		WHERE user_id = ANY($1) AND max_open_reviews IS NOT NULL
+	`, pq.Array(userIDs))
+	if err != nil {
+		r.Log.Error("GetMaxOpenReviews: query failed", zap.Error(err))
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.Log.Error("GetMaxOpenReviews: close rows failed", zap.Error(err))
+		}
+	}(rows)
+
+	limits := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var limit int
+		if err := rows.Scan(&id, &limit); err != nil {
+			r.Log.Error("GetMaxOpenReviews: scan failed", zap.Error(err))
+			return nil, err
+		}
+		limits[id] = limit
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("GetMaxOpenReviews: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("GetMaxOpenReviews: success", zap.Int("items", len(limits)))
+	return limits, nil
+}
+
+// SetUserRole changes the role of userID, a member of teamName.
+func (r *Repositories) SetUserRole(ctx context.Context, teamName, userID, role string) (model.User, error) {
+	r.Log.Debug("SetUserRole: start", zap.String("team", teamName), zap.String("user", userID), zap.String("role", role))
+	res, err := r.DB.ExecContext(ctx, `UPDATE users SET role=$3 WHERE user_id=$1 AND team_name=$2`, userID, teamName, role)
+	if err != nil {
+		r.Log.Error("SetUserRole: update failed", zap.Error(err))
+		return model.User{}, err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		r.Log.Debug("SetUserRole: member not found", zap.String("team", teamName), zap.String("user", userID))
+		return model.User{}, model.ErrNotFound
+	}
+	u, err := r.GetUser(ctx, userID)
+	if err != nil {
+		r.Log.Error("SetUserRole: fetch user failed", zap.Error(err))
+		return model.User{}, err
+	}
+	r.Log.Info("SetUserRole: success", zap.String("user", userID), zap.String("role", role))
+	return u, nil
+}
+
+// GetUserRoles returns the role of every user of userIDs.
+func (r *Repositories) GetUserRoles(ctx context.Context, userIDs []string) (map[string]string, error) {
+	r.Log.Debug("GetUserRoles: start", zap.Int("users", len(userIDs)))
+	rows, err := r.DB.QueryContext(ctx, `SELECT user_id, role FROM users WHERE user_id = ANY($1)`, pq.Array(userIDs))
+	if err != nil {
+		r.Log.Error("GetUserRoles: query failed", zap.Error(err))
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.Log.Error("GetUserRoles: close rows failed", zap.Error(err))
+		}
+	}(rows)
+
+	roles := make(map[string]string, len(userIDs))
+	for rows.Next() {
+		var id, role string
+		if err := rows.Scan(&id, &role); err != nil {
+			r.Log.Error("GetUserRoles: scan failed", zap.Error(err))
+			return nil, err
+		}
+		roles[id] = role
+	}
+	if err := rows.Err(); err != nil {
+		r.Log.Error("GetUserRoles: rows error", zap.Error(err))
+		return nil, err
+	}
+	r.Log.Debug("GetUserRoles: success", zap.Int("items", len(roles)))
+	return roles, nil
+}
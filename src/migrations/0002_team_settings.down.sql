@@ -0,0 +1,2 @@
+-- 0002_team_settings.down.sql
+DROP TABLE IF EXISTS team_settings;
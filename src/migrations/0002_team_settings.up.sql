@@ -0,0 +1,7 @@
+-- 0002_team_settings.up.sql
+CREATE TABLE IF NOT EXISTS team_settings (
+    team_name TEXT PRIMARY KEY REFERENCES teams(team_name) ON DELETE CASCADE,
+    reviewers_count INT NOT NULL DEFAULT 2 CHECK (reviewers_count >= 0),
+    min_reviewers INT NOT NULL DEFAULT 0 CHECK (min_reviewers >= 0),
+    strategy TEXT NOT NULL DEFAULT ''
+);
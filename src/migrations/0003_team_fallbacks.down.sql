@@ -0,0 +1,3 @@
+-- 0003_team_fallbacks.down.sql
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS is_fallback;
+DROP TABLE IF EXISTS team_fallbacks;
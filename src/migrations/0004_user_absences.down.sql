@@ -0,0 +1,2 @@
+-- 0004_user_absences.down.sql
+DROP TABLE IF EXISTS user_absences;
@@ -0,0 +1,2 @@
+-- 0005_pr_version.down.sql
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS version;
@@ -0,0 +1,2 @@
+-- 0005_pr_version.up.sql
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
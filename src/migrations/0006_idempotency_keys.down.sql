@@ -0,0 +1,2 @@
+-- 0006_idempotency_keys.down.sql
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,3 @@
+-- 0007_pr_reviews.down.sql
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS assigned_at;
+DROP TABLE IF EXISTS pr_reviews;
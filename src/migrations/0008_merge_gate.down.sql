@@ -0,0 +1,3 @@
+-- 0008_merge_gate.down.sql
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS force_merged;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS required_approvals;
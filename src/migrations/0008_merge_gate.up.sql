@@ -0,0 +1,3 @@
+-- 0008_merge_gate.up.sql
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS required_approvals INT NOT NULL DEFAULT 0;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS force_merged BOOLEAN NOT NULL DEFAULT FALSE;
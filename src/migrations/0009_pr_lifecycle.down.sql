@@ -0,0 +1,11 @@
+-- 0009_pr_lifecycle.down.sql
+-- Enum values cannot be dropped, so the type is rebuilt. DRAFT and CLOSED PRs become OPEN.
+UPDATE pull_requests SET status = 'OPEN' WHERE status::text IN ('DRAFT', 'CLOSED');
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS closed_at;
+ALTER TABLE pull_requests ALTER COLUMN status DROP DEFAULT;
+ALTER TYPE pr_status RENAME TO pr_status_old;
+CREATE TYPE pr_status AS ENUM ('OPEN','MERGED');
+ALTER TABLE pull_requests ALTER COLUMN status TYPE pr_status USING status::text::pr_status;
+ALTER TABLE pull_requests ALTER COLUMN status SET DEFAULT 'OPEN';
+DROP TYPE pr_status_old;
@@ -0,0 +1,2 @@
+-- 0010_pr_events.down.sql
+DROP TABLE IF EXISTS pr_events;
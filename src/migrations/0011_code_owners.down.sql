@@ -0,0 +1,3 @@
+-- 0011_code_owners.down.sql
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS changed_files;
+DROP TABLE IF EXISTS code_owner_rules;
@@ -0,0 +1,11 @@
+-- 0011_code_owners.up.sql
+-- CODEOWNERS-style routing: ordered path patterns owned by a team or a single user.
+CREATE TABLE IF NOT EXISTS code_owner_rules (
+    position INT PRIMARY KEY,
+    pattern TEXT NOT NULL,
+    team_name TEXT REFERENCES teams(team_name) ON DELETE CASCADE,
+    user_id TEXT REFERENCES users(user_id) ON DELETE CASCADE,
+    CHECK ((team_name IS NULL) <> (user_id IS NULL))
+);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS changed_files TEXT[] NOT NULL DEFAULT '{}';
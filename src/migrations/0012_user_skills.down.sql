@@ -0,0 +1,3 @@
+-- 0012_user_skills.down.sql
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS required_skills;
+DROP TABLE IF EXISTS user_skills;
@@ -0,0 +1,10 @@
+-- 0012_user_skills.up.sql
+CREATE TABLE IF NOT EXISTS user_skills (
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    skill TEXT NOT NULL,
+    PRIMARY KEY (user_id, skill)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_skills_skill ON user_skills(skill);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS required_skills TEXT[] NOT NULL DEFAULT '{}';
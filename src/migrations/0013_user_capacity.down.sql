@@ -0,0 +1,2 @@
+-- 0013_user_capacity.down.sql
+ALTER TABLE users DROP COLUMN IF EXISTS max_open_reviews;
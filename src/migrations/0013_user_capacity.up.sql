@@ -0,0 +1,3 @@
+-- 0013_user_capacity.up.sql
+-- NULL means no limit on concurrent open reviews.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS max_open_reviews INT CHECK (max_open_reviews >= 0);
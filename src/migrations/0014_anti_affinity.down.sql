@@ -0,0 +1,3 @@
+-- 0014_anti_affinity.down.sql
+DROP INDEX IF EXISTS idx_pull_requests_author_created;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS anti_affinity_window;
@@ -0,0 +1,4 @@
+-- 0014_anti_affinity.up.sql
+-- Number of the author's latest PRs whose reviewers are penalized, 0 disables anti-affinity.
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS anti_affinity_window INT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_pull_requests_author_created ON pull_requests(author_id, created_at DESC);
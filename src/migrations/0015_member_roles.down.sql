@@ -0,0 +1,4 @@
+-- 0015_member_roles.down.sql
+ALTER TABLE team_settings DROP COLUMN IF EXISTS senior_policy;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS min_senior_reviewers;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
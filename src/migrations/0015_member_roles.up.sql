@@ -0,0 +1,5 @@
+-- 0015_member_roles.up.sql
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'member'
+    CHECK (role IN ('member', 'senior', 'lead'));
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS min_senior_reviewers INT NOT NULL DEFAULT 0;
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS senior_policy TEXT NOT NULL DEFAULT '';
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,16 +22,22 @@ type TeamMember struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
+	Role     string `json:"role,omitempty"`
 }
 
 type PullRequest struct {
-	PullRequestID   string    `json:"pull_request_id"`
-	PullRequestName string    `json:"pull_request_name"`
-	AuthorID        string    `json:"author_id"`
-	Status          string    `json:"status"`
-	Assigned        []string  `json:"assigned_reviewers"`
-	CreatedAt       time.Time `json:"createdAt,omitempty"`
-	MergedAt        *string   `json:"mergedAt,omitempty"`
+	PullRequestID   string   `json:"pull_request_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	AuthorID        string   `json:"author_id"`
+	Status          string   `json:"status"`
+	Assigned        []string `json:"assigned_reviewers"`
+	Reviewers       []struct {
+		UserID  string `json:"user_id"`
+		State   string `json:"state"`
+		Comment string `json:"comment"`
+	} `json:"reviewers"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	MergedAt  *string   `json:"mergedAt,omitempty"`
 }
 
 type PullRequestShort struct {
@@ -278,7 +285,1749 @@ func (suite *IntegrationTestSuite) TestEdgeCases() {
 	fmt.Println("✅ Correctly handled user deactivation")
 }
 
+func (suite *IntegrationTestSuite) TestTeamSettings() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("settings-team-%d", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: fmt.Sprintf("settings-%d-1", suffix), Username: "User 1", IsActive: true},
+			{UserID: fmt.Sprintf("settings-%d-2", suffix), Username: "User 2", IsActive: true},
+			{UserID: fmt.Sprintf("settings-%d-3", suffix), Username: "User 3", IsActive: true},
+			{UserID: fmt.Sprintf("settings-%d-4", suffix), Username: "User 4", IsActive: true},
+		},
+	}
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{
+		"team_name":       teamName,
+		"reviewers_count": 3,
+		"min_reviewers":   2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Should update team settings")
+
+	resp, err = suite.doRequest("GET", "/team/settings?team_name="+teamName, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var settings struct {
+		ReviewersCount int `json:"reviewers_count"`
+		MinReviewers   int `json:"min_reviewers"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&settings)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, settings.ReviewersCount)
+	assert.Equal(t, 2, settings.MinReviewers)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   fmt.Sprintf("settings-pr-%d", suffix),
+		"pull_request_name": "Settings PR",
+		"author_id":         team.Members[0].UserID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var prResp struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&prResp)
+	assert.NoError(t, err)
+	assert.Len(t, prResp.PR.Assigned, 3, "Should assign reviewers_count reviewers")
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{
+		"team_name":     teamName,
+		"min_reviewers": 5,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "min_reviewers above reviewers_count should be rejected")
+	fmt.Println("✅ Team settings applied to PR creation")
+}
+
+func (suite *IntegrationTestSuite) TestFallbackTeams() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := func(name string) string { return fmt.Sprintf("fallback-%s-%d", name, suffix) }
+	user := func(name string) string { return fmt.Sprintf("fallback-%d-%s", suffix, name) }
+	for _, name := range []string{"main", "first", "second"} {
+		team := Team{TeamName: teamName(name), Members: []TeamMember{{UserID: user(name), Username: name, IsActive: true}}}
+		if name == "main" {
+			team.Members = append(team.Members, TeamMember{UserID: user("main-2"), Username: "main 2", IsActive: true})
+		}
+		resp, err := suite.doRequest("POST", "/team/add", team)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	getFallbacks := func() []string {
+		resp, err := suite.doRequest("GET", "/team/settings?team_name="+teamName("main"), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var settings struct {
+			FallbackTeams []string `json:"fallback_teams"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&settings)
+		assert.NoError(t, err)
+		return settings.FallbackTeams
+	}
+	createPR := func(i int) (assigned, fallback []string) {
+		resp, err := suite.doRequest("POST", "/pullRequest/create", map[string]string{
+			"pull_request_id":   fmt.Sprintf("fallback-pr-%d-%d", suffix, i),
+			"pull_request_name": "Fallback PR",
+			"author_id":         user("main"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var prResp struct {
+			PR struct {
+				PullRequest
+				Fallback []string `json:"fallback_reviewers"`
+			} `json:"pr"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&prResp)
+		assert.NoError(t, err)
+		return prResp.PR.Assigned, prResp.PR.Fallback
+	}
+
+	resp, err := suite.doRequest("POST", "/team/settings", map[string]any{
+		"team_name":       teamName("main"),
+		"reviewers_count": 2,
+		"fallback_teams":  []string{teamName("second"), teamName("first")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Should save fallback teams")
+	assert.Equal(t, []string{teamName("second"), teamName("first")}, getFallbacks(), "Fallback order should be persisted")
+	assigned, fallback := createPR(1)
+	assert.ElementsMatch(t, []string{user("main-2"), user("second")}, assigned, "The first fallback team should fill the slot")
+	assert.Equal(t, []string{user("second")}, fallback)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName("main"), "reviewers_count": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{teamName("second"), teamName("first")}, getFallbacks(), "Omitted fallback_teams should be kept")
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{
+		"team_name":      teamName("main"),
+		"fallback_teams": []string{teamName("first")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{teamName("first")}, getFallbacks(), "Fallback teams should be replaced")
+	assigned, fallback = createPR(2)
+	assert.ElementsMatch(t, []string{user("main-2"), user("first")}, assigned)
+	assert.Equal(t, []string{user("first")}, fallback)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{
+		"team_name":      teamName("main"),
+		"fallback_teams": []string{teamName("main")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "A team should not fall back to itself")
+	assert.Equal(t, []string{teamName("first")}, getFallbacks())
+	fmt.Println("✅ Fallback teams persisted and applied in order")
+}
+
+func (suite *IntegrationTestSuite) TestAbsenceExcludesReviewer() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("absence-team-%d", suffix)
+	author := fmt.Sprintf("absence-%d-1", suffix)
+	present := fmt.Sprintf("absence-%d-2", suffix)
+	away := fmt.Sprintf("absence-%d-3", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: present, Username: "Present", IsActive: true},
+			{UserID: away, Username: "Away", IsActive: true},
+		},
+	}
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	now := time.Now().UTC()
+	resp, err = suite.doRequest("POST", "/users/addAbsence", map[string]any{
+		"user_id":   away,
+		"starts_at": now.Add(-time.Hour),
+		"ends_at":   now.Add(24 * time.Hour),
+		"reason":    "vacation",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "Should create absence")
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   fmt.Sprintf("absence-pr-%d", suffix),
+		"pull_request_name": "Absence PR",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var prResp struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&prResp)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{present}, prResp.PR.Assigned, "Absent user should not be assigned")
+
+	resp, err = suite.doRequest("GET", "/users/getAbsences?user_id="+away, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var absencesResp struct {
+		Absences []struct {
+			AbsenceID int64  `json:"absence_id"`
+			Reason    string `json:"reason"`
+		} `json:"absences"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&absencesResp)
+	assert.NoError(t, err)
+	assert.Len(t, absencesResp.Absences, 1)
+	fmt.Println("✅ Absent reviewer skipped during assignment")
+}
+
+func (suite *IntegrationTestSuite) TestDeactivateReassignsOpenReviews() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("reassign-open-team-%d", suffix)
+	user := func(i int) string { return fmt.Sprintf("reassign-open-%d-%d", suffix, i) }
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: user(1), Username: "User 1", IsActive: true},
+			{UserID: user(2), Username: "User 2", IsActive: true},
+			{UserID: user(3), Username: "User 3", IsActive: true},
+			{UserID: user(4), Username: "User 4", IsActive: false},
+			{UserID: user(5), Username: "User 5", IsActive: false},
+		},
+	}
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 3})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// users 4 and 5 are still inactive: the first PR gets users 2 and 3, the second one, authored by user 4, everyone else
+	replaceable := fmt.Sprintf("reassign-open-pr-%d-1", suffix)
+	stuck := fmt.Sprintf("reassign-open-pr-%d-2", suffix)
+	for id, author := range map[string]string{replaceable: user(1), stuck: user(4)} {
+		resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+			"pull_request_id":   id,
+			"pull_request_name": "Reassign Open PR",
+			"author_id":         author,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	resp, err = suite.doRequest("POST", "/users/setIsActive", map[string]any{"user_id": user(4), "is_active": true})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/users/setIsActive", map[string]any{
+		"user_id":       user(3),
+		"is_active":     false,
+		"reassign_open": true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Should deactivate user and reassign reviews")
+
+	var result struct {
+		Reassignment struct {
+			Reassigned []struct {
+				PullRequestID string `json:"pull_request_id"`
+				OldReviewerID string `json:"old_reviewer_id"`
+				NewReviewerID string `json:"new_reviewer_id"`
+			} `json:"reassigned"`
+			Failed []struct {
+				PullRequestID string `json:"pull_request_id"`
+				Code          string `json:"code"`
+			} `json:"failed"`
+		} `json:"reassignment"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	assert.NoError(t, err)
+	if assert.Len(t, result.Reassignment.Reassigned, 1) {
+		assert.Equal(t, replaceable, result.Reassignment.Reassigned[0].PullRequestID)
+		assert.Equal(t, user(3), result.Reassignment.Reassigned[0].OldReviewerID)
+		assert.Equal(t, user(4), result.Reassignment.Reassigned[0].NewReviewerID)
+	}
+	if assert.Len(t, result.Reassignment.Failed, 1, "The PR without a free teammate should be reported") {
+		assert.Equal(t, stuck, result.Reassignment.Failed[0].PullRequestID)
+		assert.Equal(t, "NO_CANDIDATE", result.Reassignment.Failed[0].Code)
+	}
+
+	resp, err = suite.doRequest("GET", "/users/getReview?user_id="+user(4), nil)
+	assert.NoError(t, err)
+
+	var reviews struct {
+		PullRequests []PullRequestShort `json:"pull_requests"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&reviews)
+	assert.NoError(t, err)
+	if assert.Len(t, reviews.PullRequests, 1) {
+		assert.Equal(t, replaceable, reviews.PullRequests[0].PullRequestID, "Reassignment should be persisted")
+	}
+
+	resp, err = suite.doRequest("GET", "/users/getReview?user_id="+user(3), nil)
+	assert.NoError(t, err)
+	err = json.NewDecoder(resp.Body).Decode(&reviews)
+	assert.NoError(t, err)
+	if assert.Len(t, reviews.PullRequests, 1) {
+		assert.Equal(t, stuck, reviews.PullRequests[0].PullRequestID, "Failed reassignment should keep the reviewer")
+	}
+
+	// once a candidate is available, repeating the call picks up only the failed PR
+	resp, err = suite.doRequest("POST", "/users/setIsActive", map[string]any{"user_id": user(5), "is_active": true})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/users/setIsActive", map[string]any{
+		"user_id":       user(3),
+		"is_active":     false,
+		"reassign_open": true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Repeated deactivation should be accepted")
+
+	result.Reassignment.Reassigned, result.Reassignment.Failed = nil, nil
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	assert.NoError(t, err)
+	if assert.Len(t, result.Reassignment.Reassigned, 1) {
+		assert.Equal(t, stuck, result.Reassignment.Reassigned[0].PullRequestID)
+		assert.Equal(t, user(5), result.Reassignment.Reassigned[0].NewReviewerID)
+	}
+	assert.Empty(t, result.Reassignment.Failed)
+	fmt.Println("✅ Open reviews reassigned on deactivation")
+}
+
+func (suite *IntegrationTestSuite) TestBulkDeactivation() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("bulk-team-%d", suffix)
+	user := func(i int) string { return fmt.Sprintf("bulk-%d-%d", suffix, i) }
+	team := Team{TeamName: teamName}
+	for i := 1; i <= 5; i++ {
+		team.Members = append(team.Members, TeamMember{UserID: user(i), Username: fmt.Sprintf("User %d", i), IsActive: true})
+	}
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	for i := 0; i < 10; i++ {
+		resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+			"pull_request_id":   fmt.Sprintf("bulk-pr-%d-%d", suffix, i),
+			"pull_request_name": "Bulk PR",
+			"author_id":         user(1 + i%5),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	leaving := []string{user(2), user(3)}
+	start := time.Now()
+	resp, err = suite.doRequest("POST", "/team/deactivateUsers", map[string]any{
+		"team_name": teamName,
+		"user_ids":  leaving,
+	})
+	elapsed := time.Since(start)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Should deactivate users")
+
+	var result struct {
+		Deactivated []string `json:"deactivated"`
+		Failed      []struct {
+			PullRequestID string `json:"pull_request_id"`
+		} `json:"failed"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, leaving, result.Deactivated)
+
+	failed := map[string]bool{}
+	for _, f := range result.Failed {
+		failed[f.PullRequestID] = true
+	}
+	for _, id := range leaving {
+		resp, err = suite.doRequest("GET", "/users/getReview?user_id="+id, nil)
+		assert.NoError(t, err)
+
+		var reviews struct {
+			PullRequests []PullRequestShort `json:"pull_requests"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&reviews)
+		assert.NoError(t, err)
+		for _, pr := range reviews.PullRequests {
+			if pr.Status == "OPEN" {
+				assert.True(t, failed[pr.PullRequestID], "Open review %s should have been reassigned", pr.PullRequestID)
+			}
+		}
+	}
+	fmt.Printf("✅ Bulk deactivation finished in %v\n", elapsed)
+}
+
+func (suite *IntegrationTestSuite) TestReassignPersists() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("reassign-team-%d", suffix)
+	author := fmt.Sprintf("reassign-%d-1", suffix)
+	oldReviewer := fmt.Sprintf("reassign-%d-2", suffix)
+	newReviewer := fmt.Sprintf("reassign-%d-3", suffix)
+	prID := fmt.Sprintf("reassign-pr-%d", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: oldReviewer, Username: "Old", IsActive: true},
+			{UserID: newReviewer, Username: "New", IsActive: true},
+		},
+	}
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "Reassign PR",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	assert.NoError(t, err)
+	if !assert.Len(t, created.PR.Assigned, 1) {
+		return
+	}
+	// Reviewer is picked at random, so reassign whoever got the PR.
+	oldReviewer = created.PR.Assigned[0]
+	if oldReviewer == newReviewer {
+		newReviewer = fmt.Sprintf("reassign-%d-2", suffix)
+	}
+
+	resp, err = suite.doRequest("POST", "/pullRequest/reassign", map[string]string{
+		"pull_request_id": prID,
+		"old_user_id":     oldReviewer,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Should reassign reviewer")
+
+	var reassigned struct {
+		PR         PullRequest `json:"pr"`
+		ReplacedBy string      `json:"replaced_by"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&reassigned)
+	assert.NoError(t, err)
+	assert.Equal(t, newReviewer, reassigned.ReplacedBy)
+	assert.Equal(t, []string{newReviewer}, reassigned.PR.Assigned)
+
+	reviewsOf := func(userID string) []string {
+		resp, err := suite.doRequest("GET", "/users/getReview?user_id="+userID, nil)
+		assert.NoError(t, err)
+
+		var reviews struct {
+			PullRequests []PullRequestShort `json:"pull_requests"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&reviews)
+		assert.NoError(t, err)
+		var ids []string
+		for _, pr := range reviews.PullRequests {
+			ids = append(ids, pr.PullRequestID)
+		}
+		return ids
+	}
+	assert.NotContains(t, reviewsOf(oldReviewer), prID, "Old reviewer should no longer review the PR")
+	assert.Contains(t, reviewsOf(newReviewer), prID, "New reviewer should be stored for the PR")
+	fmt.Println("✅ Reassignment persisted")
+}
+
+func (suite *IntegrationTestSuite) TestConcurrentMergeAndReassign() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("race-team-%d", suffix)
+	user := func(i int) string { return fmt.Sprintf("race-%d-%d", suffix, i) }
+	team := Team{TeamName: teamName}
+	for i := 1; i <= 6; i++ {
+		team.Members = append(team.Members, TeamMember{UserID: user(i), Username: fmt.Sprintf("User %d", i), IsActive: true})
+	}
+	prID := fmt.Sprintf("race-pr-%d", suffix)
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "Race PR",
+		"author_id":         user(1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	assert.NoError(t, err)
+	if !assert.Len(t, created.PR.Assigned, 2) {
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	statuses := map[string][]int{}
+	record := func(kind string, status int) {
+		mu.Lock()
+		defer mu.Unlock()
+		statuses[kind] = append(statuses[kind], status)
+	}
+	for i := 0; i < 5; i++ {
+		for _, reviewer := range created.PR.Assigned {
+			wg.Add(1)
+			go func(reviewer string) {
+				defer wg.Done()
+				resp, err := suite.doRequest("POST", "/pullRequest/reassign", map[string]string{
+					"pull_request_id": prID,
+					"old_user_id":     reviewer,
+				})
+				if assert.NoError(t, err) {
+					record(reviewer, resp.StatusCode)
+				}
+			}(reviewer)
+		}
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp, err := suite.doRequest("POST", "/pullRequest/merge", map[string]string{"pull_request_id": prID})
+		if assert.NoError(t, err) {
+			record("merge", resp.StatusCode)
+		}
+	}()
+	wg.Wait()
+
+	for _, reviewer := range created.PR.Assigned {
+		ok := 0
+		for _, status := range statuses[reviewer] {
+			assert.Contains(t, []int{http.StatusOK, http.StatusConflict}, status, "Reassign should either win or report a conflict")
+			if status == http.StatusOK {
+				ok++
+			}
+		}
+		assert.LessOrEqual(t, ok, 1, "Reviewer %s can be replaced at most once", reviewer)
+	}
+	if assert.Len(t, statuses["merge"], 1) {
+		assert.Contains(t, []int{http.StatusOK, http.StatusConflict}, statuses["merge"][0])
+	}
+
+	var merged struct {
+		PR PullRequest `json:"pr"`
+	}
+	for attempt := 0; attempt < 3 && merged.PR.Status != "MERGED"; attempt++ {
+		resp, err = suite.doRequest("POST", "/pullRequest/merge", map[string]string{"pull_request_id": prID})
+		assert.NoError(t, err)
+		if resp.StatusCode == http.StatusOK {
+			err = json.NewDecoder(resp.Body).Decode(&merged)
+			assert.NoError(t, err)
+		}
+	}
+	assert.Equal(t, "MERGED", merged.PR.Status)
+	assert.Len(t, merged.PR.Assigned, 2, "Reviewer count must survive concurrent updates")
+	assert.NotContains(t, merged.PR.Assigned, user(1))
+
+	for i := 1; i <= 6; i++ {
+		resp, err = suite.doRequest("GET", "/users/getReview?user_id="+user(i), nil)
+		assert.NoError(t, err)
+
+		var reviews struct {
+			PullRequests []PullRequestShort `json:"pull_requests"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&reviews)
+		assert.NoError(t, err)
+		listed := false
+		for _, pr := range reviews.PullRequests {
+			listed = listed || pr.PullRequestID == prID
+		}
+		assert.Equal(t, contains(merged.PR.Assigned, user(i)), listed, "Review list of %s disagrees with PR state", user(i))
+	}
+	fmt.Println("✅ Concurrent merge and reassign left a consistent PR")
+}
+
+func (suite *IntegrationTestSuite) TestIfMatchPreconditions() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("etag-team-%d", suffix)
+	user := func(i int) string { return fmt.Sprintf("etag-%d-%d", suffix, i) }
+	team := Team{TeamName: teamName}
+	for i := 1; i <= 4; i++ {
+		team.Members = append(team.Members, TeamMember{UserID: user(i), Username: fmt.Sprintf("User %d", i), IsActive: true})
+	}
+	prID := fmt.Sprintf("etag-pr-%d", suffix)
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "ETag PR",
+		"author_id":         user(1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	createdTag := resp.Header.Get("ETag")
+	assert.NotEmpty(t, createdTag, "Created PR should carry an ETag")
+
+	var created struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	assert.NoError(t, err)
+	if !assert.NotEmpty(t, created.PR.Assigned) {
+		return
+	}
+
+	reassignReq := map[string]string{"pull_request_id": prID, "old_user_id": created.PR.Assigned[0]}
+	resp, err = suite.doRequestWithHeaders("POST", "/pullRequest/reassign", reassignReq, map[string]string{"If-Match": createdTag})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Reassign with current ETag should succeed")
+	reassignedTag := resp.Header.Get("ETag")
+	assert.NotEqual(t, createdTag, reassignedTag, "Reassign should bump the revision")
+
+	mergeReq := map[string]string{"pull_request_id": prID}
+	resp, err = suite.doRequestWithHeaders("POST", "/pullRequest/merge", mergeReq, map[string]string{"If-Match": createdTag})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode, "Merge with stale ETag should be rejected")
+
+	resp, err = suite.doRequestWithHeaders("POST", "/pullRequest/merge", mergeReq, map[string]string{"If-Match": reassignedTag})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Merge with current ETag should succeed")
+	assert.NotEqual(t, reassignedTag, resp.Header.Get("ETag"))
+	fmt.Println("✅ If-Match preconditions enforced")
+}
+
+func (suite *IntegrationTestSuite) TestIdempotencyKey() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("idem-team-%d", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: fmt.Sprintf("idem-%d-1", suffix), Username: "Author", IsActive: true},
+			{UserID: fmt.Sprintf("idem-%d-2", suffix), Username: "Reviewer", IsActive: true},
+		},
+	}
+	teamKey := map[string]string{"Idempotency-Key": fmt.Sprintf("team-%d", suffix)}
+
+	resp, err := suite.doRequestWithHeaders("POST", "/team/add", team, teamKey)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequestWithHeaders("POST", "/team/add", team, teamKey)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "Retry should replay the original response instead of TEAM_EXISTS")
+	assert.Equal(t, "true", resp.Header.Get("Idempotent-Replayed"))
+
+	prReq := map[string]string{
+		"pull_request_id":   fmt.Sprintf("idem-pr-%d", suffix),
+		"pull_request_name": "Idempotent PR",
+		"author_id":         team.Members[0].UserID,
+	}
+	prKey := map[string]string{"Idempotency-Key": fmt.Sprintf("pr-%d", suffix)}
+
+	var first, second struct {
+		PR PullRequest `json:"pr"`
+	}
+	resp, err = suite.doRequestWithHeaders("POST", "/pullRequest/create", prReq, prKey)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&first)
+	assert.NoError(t, err)
+	firstTag := resp.Header.Get("ETag")
+
+	resp, err = suite.doRequestWithHeaders("POST", "/pullRequest/create", prReq, prKey)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "Retry should replay the original response instead of PR_EXISTS")
+	err = json.NewDecoder(resp.Body).Decode(&second)
+	assert.NoError(t, err)
+	assert.Equal(t, first.PR.Assigned, second.PR.Assigned)
+	assert.Equal(t, firstTag, resp.Header.Get("ETag"))
+
+	prReq["pull_request_name"] = "Different body"
+	resp, err = suite.doRequestWithHeaders("POST", "/pullRequest/create", prReq, prKey)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "Reused key with another body should be rejected")
+	fmt.Println("✅ Idempotency-Key replays responses")
+}
+
+func (suite *IntegrationTestSuite) TestReviewDecisions() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("review-team-%d", suffix)
+	author := fmt.Sprintf("review-%d-1", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: fmt.Sprintf("review-%d-2", suffix), Username: "Reviewer 1", IsActive: true},
+			{UserID: fmt.Sprintf("review-%d-3", suffix), Username: "Reviewer 2", IsActive: true},
+		},
+	}
+	prID := fmt.Sprintf("review-pr-%d", suffix)
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "Review PR",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	assert.NoError(t, err)
+	if !assert.Len(t, created.PR.Reviewers, 2) {
+		return
+	}
+	for _, r := range created.PR.Reviewers {
+		assert.Equal(t, "PENDING", r.State)
+	}
+	reviewer := created.PR.Assigned[0]
+
+	resp, err = suite.doRequest("POST", "/pullRequest/review", map[string]string{
+		"pull_request_id": prID,
+		"user_id":         reviewer,
+		"decision":        "CHANGES_REQUESTED",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/review", map[string]string{
+		"pull_request_id": prID,
+		"user_id":         reviewer,
+		"decision":        "APPROVED",
+		"comment":         "LGTM",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var reviewed struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&reviewed)
+	assert.NoError(t, err)
+	for _, r := range reviewed.PR.Reviewers {
+		if r.UserID == reviewer {
+			assert.Equal(t, "APPROVED", r.State, "Latest decision should win")
+			assert.Equal(t, "LGTM", r.Comment)
+		} else {
+			assert.Equal(t, "PENDING", r.State)
+		}
+	}
+
+	resp, err = suite.doRequest("POST", "/pullRequest/review", map[string]string{
+		"pull_request_id": prID,
+		"user_id":         author,
+		"decision":        "APPROVED",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "Only assigned reviewers can review")
+
+	resp, err = suite.doRequest("POST", "/pullRequest/review", map[string]string{
+		"pull_request_id": prID,
+		"user_id":         reviewer,
+		"decision":        "MAYBE",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	fmt.Println("✅ Review decisions recorded")
+}
+
+func (suite *IntegrationTestSuite) TestMergeGate() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("gate-team-%d", suffix)
+	author := fmt.Sprintf("gate-%d-1", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: fmt.Sprintf("gate-%d-2", suffix), Username: "Reviewer 1", IsActive: true},
+			{UserID: fmt.Sprintf("gate-%d-3", suffix), Username: "Reviewer 2", IsActive: true},
+		},
+	}
+	prID := fmt.Sprintf("gate-pr-%d", suffix)
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "required_approvals": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "Gate PR",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	assert.NoError(t, err)
+	if !assert.Len(t, created.PR.Assigned, 2) {
+		return
+	}
+
+	mergeReq := map[string]any{"pull_request_id": prID}
+	resp, err = suite.doRequest("POST", "/pullRequest/merge", mergeReq)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "Merge without approvals should be blocked")
+
+	var blocked struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				MissingApprovals int      `json:"missing_approvals"`
+				AwaitingApproval []string `json:"awaiting_approval"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&blocked)
+	assert.NoError(t, err)
+	assert.Equal(t, "MERGE_BLOCKED", blocked.Error.Code)
+	assert.Equal(t, 1, blocked.Error.Details.MissingApprovals)
+	assert.ElementsMatch(t, created.PR.Assigned, blocked.Error.Details.AwaitingApproval)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/merge", map[string]any{"pull_request_id": prID, "force": true})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "Force merge requires admin token")
+
+	resp, err = suite.doRequest("POST", "/pullRequest/review", map[string]string{
+		"pull_request_id": prID,
+		"user_id":         created.PR.Assigned[0],
+		"decision":        "APPROVED",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/merge", mergeReq)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Merge with enough approvals should pass")
+	fmt.Println("✅ Merge gate enforced")
+}
+
+func (suite *IntegrationTestSuite) TestPRLifecycle() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("life-team-%d", suffix)
+	author := fmt.Sprintf("life-%d-1", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: fmt.Sprintf("life-%d-2", suffix), Username: "Reviewer 1", IsActive: true},
+			{UserID: fmt.Sprintf("life-%d-3", suffix), Username: "Reviewer 2", IsActive: true},
+		},
+	}
+	prID := fmt.Sprintf("life-pr-%d", suffix)
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var prResp struct {
+		PR PullRequest `json:"pr"`
+	}
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]any{
+		"pull_request_id":   prID,
+		"pull_request_name": "Lifecycle PR",
+		"author_id":         author,
+		"draft":             true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&prResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "DRAFT", prResp.PR.Status)
+	assert.Empty(t, prResp.PR.Assigned, "Draft PR should have no reviewers")
+
+	req := map[string]string{"pull_request_id": prID}
+	resp, err = suite.doRequest("POST", "/pullRequest/merge", req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "Draft PR cannot be merged")
+
+	resp, err = suite.doRequest("POST", "/pullRequest/ready", req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&prResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "OPEN", prResp.PR.Status)
+	if !assert.Len(t, prResp.PR.Assigned, 2, "Ready PR should get reviewers") {
+		return
+	}
+	reviewer := prResp.PR.Assigned[0]
+
+	resp, err = suite.doRequest("POST", "/pullRequest/close", req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&prResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "CLOSED", prResp.PR.Status)
+	assert.Empty(t, prResp.PR.Assigned, "Closed PR should release reviewers")
+
+	resp, err = suite.doRequest("GET", "/users/getReview?user_id="+reviewer, nil)
+	assert.NoError(t, err)
+	var reviews struct {
+		PullRequests []PullRequestShort `json:"pull_requests"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&reviews)
+	assert.NoError(t, err)
+	assert.Empty(t, reviews.PullRequests)
+
+	var errResp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	resp, err = suite.doRequest("POST", "/pullRequest/merge", req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "PR_CLOSED", errResp.Error.Code)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/reopen", req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&prResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "OPEN", prResp.PR.Status)
+	assert.Len(t, prResp.PR.Assigned, 2, "Reopened PR should get reviewers again")
+
+	resp, err = suite.doRequest("POST", "/pullRequest/reopen", req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "PR_NOT_CLOSED", errResp.Error.Code)
+	fmt.Println("✅ PR lifecycle transitions work")
+}
+
+func (suite *IntegrationTestSuite) TestAddRemoveReviewer() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("manual-team-%d", suffix)
+	author := fmt.Sprintf("manual-%d-1", suffix)
+	extra := fmt.Sprintf("manual-%d-4", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: fmt.Sprintf("manual-%d-2", suffix), Username: "Reviewer 1", IsActive: true},
+			{UserID: fmt.Sprintf("manual-%d-3", suffix), Username: "Reviewer 2", IsActive: true},
+			{UserID: extra, Username: "Reviewer 3", IsActive: true},
+		},
+	}
+	prID := fmt.Sprintf("manual-pr-%d", suffix)
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 1, "min_reviewers": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "Manual reviewers PR",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var prResp struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&prResp)
+	assert.NoError(t, err)
+	if !assert.Len(t, prResp.PR.Assigned, 1) {
+		return
+	}
+	first := prResp.PR.Assigned[0]
+	second := extra
+	if first == extra {
+		second = fmt.Sprintf("manual-%d-2", suffix)
+	}
+
+	var errResp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	resp, err = suite.doRequest("POST", "/pullRequest/addReviewer", map[string]string{"pull_request_id": prID, "user_id": author})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "REVIEWER_IS_AUTHOR", errResp.Error.Code)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/removeReviewer", map[string]string{"pull_request_id": prID, "user_id": first})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "Removing the only reviewer should violate min_reviewers")
+	err = json.NewDecoder(resp.Body).Decode(&errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "NOT_ENOUGH_REVIEWERS", errResp.Error.Code)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/addReviewer", map[string]string{"pull_request_id": prID, "user_id": second})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&prResp)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{first, second}, prResp.PR.Assigned)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/removeReviewer", map[string]string{"pull_request_id": prID, "user_id": first})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&prResp)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{second}, prResp.PR.Assigned)
+	fmt.Println("✅ Manual reviewer changes validated")
+}
+
+func (suite *IntegrationTestSuite) TestReassignToRequestedUser() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("pick-team-%d", suffix)
+	author := fmt.Sprintf("pick-%d-1", suffix)
+	inactive := fmt.Sprintf("pick-%d-4", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: fmt.Sprintf("pick-%d-2", suffix), Username: "Reviewer 1", IsActive: true},
+			{UserID: fmt.Sprintf("pick-%d-3", suffix), Username: "Reviewer 2", IsActive: true},
+			{UserID: inactive, Username: "Inactive", IsActive: false},
+		},
+	}
+	prID := fmt.Sprintf("pick-pr-%d", suffix)
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "Requested reviewer PR",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	assert.NoError(t, err)
+	if !assert.Len(t, created.PR.Assigned, 1) {
+		return
+	}
+	oldReviewer := created.PR.Assigned[0]
+	requested := fmt.Sprintf("pick-%d-2", suffix)
+	if oldReviewer == requested {
+		requested = fmt.Sprintf("pick-%d-3", suffix)
+	}
+
+	for newUser, code := range map[string]string{
+		author:      "REVIEWER_IS_AUTHOR",
+		inactive:    "REVIEWER_INACTIVE",
+		oldReviewer: "ALREADY_ASSIGNED",
+	} {
+		resp, err = suite.doRequest("POST", "/pullRequest/reassign", map[string]string{
+			"pull_request_id": prID,
+			"old_user_id":     oldReviewer,
+			"new_user_id":     newUser,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var errResp struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&errResp)
+		assert.NoError(t, err)
+		assert.Equal(t, code, errResp.Error.Code, "Reassign to %s", newUser)
+	}
+
+	resp, err = suite.doRequest("POST", "/pullRequest/reassign", map[string]string{
+		"pull_request_id": prID,
+		"old_user_id":     oldReviewer,
+		"new_user_id":     requested,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var reassigned struct {
+		PR         PullRequest `json:"pr"`
+		ReplacedBy string      `json:"replaced_by"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&reassigned)
+	assert.NoError(t, err)
+	assert.Equal(t, requested, reassigned.ReplacedBy)
+	assert.Equal(t, []string{requested}, reassigned.PR.Assigned)
+	fmt.Println("✅ Reassign to requested user validated")
+}
+
+func (suite *IntegrationTestSuite) TestPRHistory() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("history-team-%d", suffix)
+	author := fmt.Sprintf("history-%d-1", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: fmt.Sprintf("history-%d-2", suffix), Username: "Reviewer 1", IsActive: true},
+			{UserID: fmt.Sprintf("history-%d-3", suffix), Username: "Reviewer 2", IsActive: true},
+		},
+	}
+	prID := fmt.Sprintf("history-pr-%d", suffix)
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 1, "strategy": "round_robin"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "History PR",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		PR PullRequest `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	assert.NoError(t, err)
+	if !assert.Len(t, created.PR.Assigned, 1) {
+		return
+	}
+
+	resp, err = suite.doRequest("POST", "/pullRequest/reassign", map[string]string{
+		"pull_request_id": prID,
+		"old_user_id":     created.PR.Assigned[0],
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/merge", map[string]string{"pull_request_id": prID})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("GET", "/pullRequest/history?pull_request_id="+prID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var history struct {
+		Events []struct {
+			Type string         `json:"type"`
+			Data map[string]any `json:"data"`
+		} `json:"events"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&history)
+	assert.NoError(t, err)
+	var types []string
+	for _, e := range history.Events {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []string{"CREATED", "REVIEWERS_ASSIGNED", "REVIEWER_REASSIGNED", "MERGED"}, types)
+	if len(history.Events) == 4 {
+		assert.Equal(t, "round_robin", history.Events[1].Data["strategy"])
+		assert.NotEmpty(t, history.Events[1].Data["seed"], "Assignment seed should be recorded")
+		assert.Equal(t, created.PR.Assigned[0], history.Events[2].Data["from"])
+	}
+
+	resp, err = suite.doRequest("GET", "/pullRequest/history?pull_request_id=missing-"+prID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	fmt.Println("✅ PR history recorded")
+}
+
+func (suite *IntegrationTestSuite) TestAssignmentExplanation() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("explain-team-%d", suffix)
+	author := fmt.Sprintf("explain-%d-1", suffix)
+	inactive := fmt.Sprintf("explain-%d-3", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: fmt.Sprintf("explain-%d-2", suffix), Username: "Reviewer", IsActive: true},
+			{UserID: inactive, Username: "Inactive", IsActive: false},
+		},
+	}
+	prID := fmt.Sprintf("explain-pr-%d", suffix)
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 1, "strategy": "least_loaded"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create?explain=true", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "Explained PR",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		PR          PullRequest `json:"pr"`
+		Explanation struct {
+			Strategy string `json:"strategy"`
+			Seed     string `json:"seed"`
+			Pools    []struct {
+				Team       string   `json:"team"`
+				Candidates []string `json:"candidates"`
+				Excluded   []struct {
+					UserID string `json:"user_id"`
+					Reason string `json:"reason"`
+				} `json:"excluded"`
+				Inputs map[string]any `json:"inputs"`
+			} `json:"pools"`
+			Picked []string `json:"picked"`
+		} `json:"explanation"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	assert.NoError(t, err)
+	assert.Equal(t, "least_loaded", created.Explanation.Strategy)
+	assert.NotEmpty(t, created.Explanation.Seed)
+	assert.Equal(t, created.PR.Assigned, created.Explanation.Picked)
+	if assert.Len(t, created.Explanation.Pools, 1) {
+		pool := created.Explanation.Pools[0]
+		assert.Equal(t, teamName, pool.Team)
+		assert.Equal(t, []string{fmt.Sprintf("explain-%d-2", suffix)}, pool.Candidates)
+		reasons := map[string]string{}
+		for _, e := range pool.Excluded {
+			reasons[e.UserID] = e.Reason
+		}
+		assert.Equal(t, map[string]string{author: "author", inactive: "inactive"}, reasons)
+		assert.Contains(t, pool.Inputs, "open_reviews")
+	}
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   prID + "-plain",
+		"pull_request_name": "Plain PR",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var plain map[string]any
+	err = json.NewDecoder(resp.Body).Decode(&plain)
+	assert.NoError(t, err)
+	assert.NotContains(t, plain, "explanation", "Explanation is only returned on request")
+	fmt.Println("✅ Assignment explanation returned")
+}
+
+func (suite *IntegrationTestSuite) TestPreviewAssignment() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("preview-team-%d", suffix)
+	author := fmt.Sprintf("preview-%d-1", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: fmt.Sprintf("preview-%d-2", suffix), Username: "Reviewer 1", IsActive: true},
+			{UserID: fmt.Sprintf("preview-%d-3", suffix), Username: "Reviewer 2", IsActive: true},
+			{UserID: fmt.Sprintf("preview-%d-4", suffix), Username: "Inactive", IsActive: false},
+		},
+	}
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 1, "strategy": "round_robin"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var previews [2]struct {
+		TeamName  string   `json:"team_name"`
+		Strategy  string   `json:"strategy"`
+		Reviewers []string `json:"reviewers"`
+		Eligible  []string `json:"eligible"`
+	}
+	for i := range previews {
+		resp, err = suite.doRequest("POST", "/pullRequest/preview", map[string]string{"author_id": author})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.NewDecoder(resp.Body).Decode(&previews[i])
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, teamName, previews[0].TeamName)
+	assert.Equal(t, "round_robin", previews[0].Strategy)
+	assert.Len(t, previews[0].Reviewers, 1)
+	assert.ElementsMatch(t, []string{fmt.Sprintf("preview-%d-2", suffix), fmt.Sprintf("preview-%d-3", suffix)}, previews[0].Eligible)
+	assert.Equal(t, previews[0].Reviewers, previews[1].Reviewers, "Preview must not advance the round robin")
+
+	resp, err = suite.doRequest("GET", "/users/getReview?user_id="+previews[0].Reviewers[0], nil)
+	assert.NoError(t, err)
+	var reviews struct {
+		PullRequests []PullRequestShort `json:"pull_requests"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&reviews)
+	assert.NoError(t, err)
+	assert.Empty(t, reviews.PullRequests, "Preview must not create a PR")
+
+	resp, err = suite.doRequest("POST", "/pullRequest/preview", map[string]string{"author_id": "missing-" + author})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	fmt.Println("✅ Assignment preview is a dry run")
+}
+
+func (suite *IntegrationTestSuite) TestCodeOwnersRouting() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	authorTeam := fmt.Sprintf("owners-app-%d", suffix)
+	docsTeam := fmt.Sprintf("owners-docs-%d", suffix)
+	author := fmt.Sprintf("owners-%d-1", suffix)
+	writer := fmt.Sprintf("owners-%d-9", suffix)
+	for _, team := range []Team{
+		{TeamName: authorTeam, Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: fmt.Sprintf("owners-%d-2", suffix), Username: "Dev 1", IsActive: true},
+			{UserID: fmt.Sprintf("owners-%d-3", suffix), Username: "Dev 2", IsActive: true},
+		}},
+		{TeamName: docsTeam, Members: []TeamMember{{UserID: writer, Username: "Writer", IsActive: true}}},
+	} {
+		resp, err := suite.doRequest("POST", "/team/add", team)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	docsDir := fmt.Sprintf("docs-%d/", suffix)
+	resp, err := suite.doRequest("POST", "/codeowners/set", map[string]any{"rules": []map[string]string{
+		{"pattern": docsDir, "team_name": docsTeam},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	defer func() {
+		_, _ = suite.doRequest("POST", "/codeowners/set", map[string]any{"rules": []any{}})
+	}()
+
+	resp, err = suite.doRequest("POST", "/codeowners/set", map[string]any{"rules": []map[string]string{
+		{"pattern": "*.go"},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = suite.doRequest("GET", "/codeowners/get", nil)
+	assert.NoError(t, err)
+	var rules struct {
+		Rules []struct {
+			Pattern  string `json:"pattern"`
+			TeamName string `json:"team_name"`
+		} `json:"rules"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&rules)
+	assert.NoError(t, err)
+	if assert.Len(t, rules.Rules, 1, "An invalid rule set must not replace the stored one") {
+		assert.Equal(t, docsTeam, rules.Rules[0].TeamName)
+	}
+
+	prID := fmt.Sprintf("owners-pr-%d", suffix)
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]any{
+		"pull_request_id":   prID,
+		"pull_request_name": "Owned change",
+		"author_id":         author,
+		"changed_files":     []string{docsDir + "guide.md", "cmd/main.go"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		PR struct {
+			PullRequest
+			ChangedFiles []string `json:"changed_files"`
+		} `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{docsDir + "guide.md", "cmd/main.go"}, created.PR.ChangedFiles)
+	if assert.Len(t, created.PR.Assigned, 2) {
+		assert.Contains(t, created.PR.Assigned, writer, "The owning team must get a reviewer")
+	}
+	fmt.Println("✅ Code owners are assigned by changed paths")
+}
+
+func (suite *IntegrationTestSuite) TestSkillMatchedAssignment() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("skills-team-%d", suffix)
+	author := fmt.Sprintf("skills-%d-1", suffix)
+	dba := fmt.Sprintf("skills-%d-2", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: dba, Username: "DBA", IsActive: true},
+			{UserID: fmt.Sprintf("skills-%d-3", suffix), Username: "Dev 1", IsActive: true},
+			{UserID: fmt.Sprintf("skills-%d-4", suffix), Username: "Dev 2", IsActive: true},
+		},
+	}
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/users/setSkills", map[string]any{"user_id": dba, "skills": []string{"SQL", "go"}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("GET", "/users/getSkills?user_id="+dba, nil)
+	assert.NoError(t, err)
+	var skills struct {
+		Skills []string `json:"skills"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&skills)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"go", "sql"}, skills.Skills)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]any{
+		"pull_request_id":   fmt.Sprintf("skills-pr-%d", suffix),
+		"pull_request_name": "Schema change",
+		"author_id":         author,
+		"required_skills":   []string{"sql", "frontend"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		PR struct {
+			PullRequest
+			RequiredSkills  []string `json:"required_skills"`
+			UncoveredSkills []string `json:"uncovered_skills"`
+		} `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dba}, created.PR.Assigned, "The only reviewer with sql should be picked")
+	assert.Equal(t, []string{"sql", "frontend"}, created.PR.RequiredSkills)
+	assert.Equal(t, []string{"frontend"}, created.PR.UncoveredSkills)
+	fmt.Println("✅ Reviewers are matched by skills")
+}
+
+func (suite *IntegrationTestSuite) TestReviewerCapacity() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("capacity-team-%d", suffix)
+	author := fmt.Sprintf("capacity-%d-1", suffix)
+	busy := fmt.Sprintf("capacity-%d-2", suffix)
+	free := fmt.Sprintf("capacity-%d-3", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: busy, Username: "Busy", IsActive: true},
+			{UserID: free, Username: "Free", IsActive: true},
+		},
+	}
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/users/setMaxOpenReviews", map[string]any{"user_id": busy, "max_open_reviews": 0})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated struct {
+		User struct {
+			MaxOpenReviews *int `json:"max_open_reviews"`
+		} `json:"user"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&updated)
+	assert.NoError(t, err)
+	if assert.NotNil(t, updated.User.MaxOpenReviews) {
+		assert.Equal(t, 0, *updated.User.MaxOpenReviews)
+	}
+
+	prID := fmt.Sprintf("capacity-pr-%d", suffix)
+	for i := 0; i < 3; i++ {
+		resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]any{
+			"pull_request_id":   fmt.Sprintf("%s-%d", prID, i),
+			"pull_request_name": "Capacity",
+			"author_id":         author,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var created struct {
+			PR PullRequest `json:"pr"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&created)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{free}, created.PR.Assigned, "A reviewer at capacity should not be picked")
+	}
+
+	var errResp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	resp, err = suite.doRequest("POST", "/pullRequest/addReviewer", map[string]string{"pull_request_id": prID + "-0", "user_id": busy})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "CAPACITY_EXCEEDED", errResp.Error.Code)
+
+	resp, err = suite.doRequest("POST", "/users/setMaxOpenReviews", map[string]any{"user_id": busy, "max_open_reviews": nil})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/addReviewer", map[string]string{"pull_request_id": prID + "-0", "user_id": busy})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Removing the limit should make the user assignable again")
+	fmt.Println("✅ Reviewers at capacity are skipped")
+}
+
+func (suite *IntegrationTestSuite) TestAntiAffinityAndFairness() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("affinity-team-%d", suffix)
+	author := fmt.Sprintf("affinity-%d-1", suffix)
+	first := fmt.Sprintf("affinity-%d-2", suffix)
+	second := fmt.Sprintf("affinity-%d-3", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: first, Username: "Reviewer 1", IsActive: true},
+			{UserID: second, Username: "Reviewer 2", IsActive: true},
+		},
+	}
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 1, "anti_affinity_window": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var previous string
+	for i := 0; i < 4; i++ {
+		resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]any{
+			"pull_request_id":   fmt.Sprintf("affinity-pr-%d-%d", suffix, i),
+			"pull_request_name": "Affinity",
+			"author_id":         author,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var created struct {
+			PR PullRequest `json:"pr"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&created)
+		assert.NoError(t, err)
+		if assert.Len(t, created.PR.Assigned, 1) {
+			assert.NotEqual(t, previous, created.PR.Assigned[0], "The reviewer of the previous PR should not be picked again")
+			previous = created.PR.Assigned[0]
+		}
+	}
+
+	resp, err = suite.doRequest("GET", "/team/fairness?team_name="+teamName, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var report struct {
+		Members []string                  `json:"members"`
+		Pairs   map[string]map[string]int `json:"pairs"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&report)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{author, first, second}, report.Members)
+	assert.Equal(t, map[string]int{first: 2, second: 2}, report.Pairs[author])
+	assert.Equal(t, map[string]int{author: 0, second: 0}, report.Pairs[first])
+	fmt.Println("✅ Reviews are spread across the team")
+}
+
+func (suite *IntegrationTestSuite) TestSeniorReviewerPolicy() {
+	t := suite.T()
+
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("senior-team-%d", suffix)
+	author := fmt.Sprintf("senior-%d-1", suffix)
+	senior := fmt.Sprintf("senior-%d-2", suffix)
+	junior := fmt.Sprintf("senior-%d-3", suffix)
+	team := Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, Username: "Author", IsActive: true},
+			{UserID: senior, Username: "Senior", IsActive: true, Role: "senior"},
+			{UserID: junior, Username: "Junior", IsActive: true},
+		},
+	}
+
+	resp, err := suite.doRequest("POST", "/team/add", team)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "reviewers_count": 1, "min_senior_reviewers": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	prID := fmt.Sprintf("senior-pr-%d", suffix)
+	for i := 0; i < 2; i++ {
+		resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]any{
+			"pull_request_id":   fmt.Sprintf("%s-%d", prID, i),
+			"pull_request_name": "Senior",
+			"author_id":         author,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var created struct {
+			PR PullRequest `json:"pr"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&created)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{senior}, created.PR.Assigned, "The only senior should fill the required senior slot")
+	}
+
+	resp, err = suite.doRequest("POST", "/team/setMemberRole", map[string]string{"team_name": teamName, "user_id": senior, "role": "member"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]any{
+		"pull_request_id":   prID + "-fail",
+		"pull_request_name": "Senior",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	var errResp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "NOT_ENOUGH_SENIORS", errResp.Error.Code)
+
+	resp, err = suite.doRequest("POST", "/team/settings", map[string]any{"team_name": teamName, "senior_policy": "warn"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = suite.doRequest("POST", "/pullRequest/create", map[string]any{
+		"pull_request_id":   prID + "-warn",
+		"pull_request_name": "Senior",
+		"author_id":         author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var warned struct {
+		PR struct {
+			Assigned       []string `json:"assigned_reviewers"`
+			MissingSeniors int      `json:"missing_seniors"`
+		} `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&warned)
+	assert.NoError(t, err)
+	assert.Len(t, warned.PR.Assigned, 1)
+	assert.Equal(t, 1, warned.PR.MissingSeniors, "The warn policy should assign anyway and report the shortfall")
+	fmt.Println("✅ Senior reviewer policy is enforced")
+}
+
+func contains(items []string, v string) bool {
+	for _, it := range items {
+		if it == v {
+			return true
+		}
+	}
+	return false
+}
+
 func (suite *IntegrationTestSuite) doRequest(method, path string, body interface{}) (*http.Response, error) {
+	return suite.doRequestWithHeaders(method, path, body, nil)
+}
+
+func (suite *IntegrationTestSuite) doRequestWithHeaders(method, path string, body interface{}, headers map[string]string) (*http.Response, error) {
 	var req *http.Request
 	var err error
 
@@ -295,6 +2044,9 @@ func (suite *IntegrationTestSuite) doRequest(method, path string, body interface
 			return nil, err
 		}
 	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
 
 	return suite.client.Do(req)
 }